@@ -0,0 +1,156 @@
+// Command backfill re-enriches every user matching a filter, bypassing the enrichment cache.
+//
+// Usage:
+//
+//	go run ./cmd/backfill -nationality-missing -concurrency 4 -rate 5
+//	go run ./cmd/backfill -enriched-before 2025-01-01 -dry-run
+package main
+
+import (
+	"TestTask/internal/config"
+	"TestTask/internal/database"
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"TestTask/internal/service"
+	"TestTask/pkg/enrich"
+	"TestTask/pkg/logger"
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func main() {
+	var (
+		filter         repository.UserFilter
+		ageMin, ageMax int
+		enrichedBefore string
+		nationalities  string
+		concurrency    = flag.Int("concurrency", 4, "number of users enriched in parallel")
+		rate           = flag.Float64("rate", 5, "maximum users enriched per second, 0 for no limit")
+		batch          = flag.Int("batch", 100, "users loaded from the database per query")
+		dryRun         = flag.Bool("dry-run", false, "only list the matching users")
+	)
+	flag.StringVar(&filter.Gender, "gender", "", "only users with this gender")
+	flag.StringVar(&nationalities, "nationality", "", "only users with one of these comma-separated nationalities")
+	flag.BoolVar(&filter.NationalityMissing, "nationality-missing", false, "only users without a nationality")
+	flag.StringVar(&filter.EnrichmentStatus, "status", "", "only users with this enrichment status")
+	flag.IntVar(&ageMin, "age-min", -1, "minimum age")
+	flag.IntVar(&ageMax, "age-max", -1, "maximum age")
+	flag.StringVar(&enrichedBefore, "enriched-before", "", "only users enriched before this date (RFC 3339 or YYYY-MM-DD)")
+	flags := config.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	for _, code := range strings.Split(nationalities, ",") {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			filter.Nationalities = append(filter.Nationalities, code)
+		}
+	}
+	if ageMin >= 0 {
+		filter.AgeMin = &ageMin
+	}
+	if ageMax >= 0 {
+		filter.AgeMax = &ageMax
+	}
+	if enrichedBefore != "" {
+		t, err := time.Parse(time.RFC3339, enrichedBefore)
+		if err != nil {
+			t, err = time.Parse(time.DateOnly, enrichedBefore)
+		}
+		if err != nil {
+			logger.Fatal("Invalid -enriched-before", "err", err)
+		}
+		filter.EnrichedBefore = &t
+	}
+	if *concurrency <= 0 || *batch <= 0 {
+		logger.Fatal("-concurrency and -batch must be positive")
+	}
+
+	cfg, err := flags.Load()
+	if err != nil {
+		logger.Fatal("Invalid configuration", "err", err)
+	}
+	if err := logger.Init(os.Stdout, cfg.Log.Format, cfg.Log.Level); err != nil {
+		logger.Fatal("Invalid log config", "err", err)
+	}
+	database.ConnectToDB(cfg)
+	repo := repository.NewGormUserRepository(database.DB)
+	enricher, err := enrich.New(cfg, database.DB)
+	if err != nil {
+		logger.Fatal("Could not configure enrichment", "err", err)
+	}
+	svc := service.NewUserService(repo, enricher, nil, logger.Logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var limiter <-chan time.Time
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	var (
+		users        = make(chan models.User)
+		wg           sync.WaitGroup
+		done, failed atomic.Int64
+	)
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for user := range users {
+				if limiter != nil {
+					select {
+					case <-ctx.Done():
+						continue
+					case <-limiter:
+					}
+				}
+				if err := svc.Reenrich(ctx, &user); err != nil {
+					failed.Add(1)
+					logger.Logger.Error("Could not re-enrich user", "user_id", user.ID, "name", user.Name, "err", err)
+					continue
+				}
+				done.Add(1)
+			}
+		}()
+	}
+
+	var afterID uint
+	var matched int
+	for ctx.Err() == nil {
+		page, err := repo.ListAfter(ctx, filter, afterID, *batch)
+		if err != nil {
+			logger.Logger.Error("Could not load users", "err", err)
+			break
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, user := range page {
+			matched++
+			if *dryRun {
+				logger.Logger.Info("Would re-enrich user", "user_id", user.ID, "name", user.Name, "surname", user.Surname)
+				continue
+			}
+			select {
+			case users <- user:
+			case <-ctx.Done():
+			}
+		}
+		afterID = page[len(page)-1].ID
+	}
+	close(users)
+	wg.Wait()
+
+	logger.Logger.Info("Backfill finished", "matched", matched, "reenriched", done.Load(), "failed", failed.Load())
+	if failed.Load() > 0 || ctx.Err() != nil {
+		os.Exit(1)
+	}
+}
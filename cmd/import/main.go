@@ -0,0 +1,86 @@
+// Command import loads users from a CSV or NDJSON file, enriches and stores them,
+// and prints a per-row report as JSON.
+//
+// Usage:
+//
+//	go run ./cmd/import -format csv users.csv
+//	cat users.ndjson | go run ./cmd/import -format ndjson
+package main
+
+import (
+	"TestTask/internal/config"
+	"TestTask/internal/database"
+	"TestTask/internal/importer"
+	"TestTask/internal/repository"
+	"TestTask/internal/service"
+	"TestTask/pkg/enrich"
+	"TestTask/pkg/logger"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var (
+		format      = flag.String("format", "", "input format: csv or ndjson (default: from the file extension)")
+		concurrency = flag.Int("concurrency", importer.DefaultConcurrency, "number of rows enriched in parallel")
+		batch       = flag.Int("batch", importer.DefaultBatchSize, "users inserted per statement")
+	)
+	flags := config.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	// Keep stdout for the report.
+	logger.Init(os.Stderr, "", "")
+	cfg, err := flags.Load()
+	if err != nil {
+		logger.Fatal("Invalid configuration", "err", err)
+	}
+	if err := logger.Init(os.Stderr, cfg.Log.Format, cfg.Log.Level); err != nil {
+		logger.Fatal("Invalid log config", "err", err)
+	}
+
+	var in io.Reader = os.Stdin
+	if path := flag.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			logger.Fatal("Could not open input", "err", err)
+		}
+		defer f.Close()
+		in = f
+		if *format == "" {
+			*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+			if *format == "jsonl" {
+				*format = importer.FormatNDJSON
+			}
+		}
+	}
+
+	database.ConnectToDB(cfg)
+	enricher, err := enrich.New(cfg, database.DB)
+	if err != nil {
+		logger.Fatal("Could not configure enrichment", "err", err)
+	}
+	svc := service.NewUserService(repository.NewGormUserRepository(database.DB), enricher, nil, logger.Logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := svc.ImportUsers(ctx, in, *format, importer.Options{Concurrency: *concurrency, BatchSize: *batch})
+	if report != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+		logger.Logger.Info("Import finished", "imported", report.Imported, "total", report.Total, "failed", report.Failed)
+	}
+	if err != nil {
+		logger.Fatal("Import failed", "err", err)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -2,12 +2,24 @@ package main
 
 import (
 	_ "TestTask/docs"
+	v1 "TestTask/internal/api/v1"
 	"TestTask/internal/config"
 	"TestTask/internal/database"
+	"TestTask/internal/enrichment"
+	"TestTask/internal/handler"
+	"TestTask/internal/reload"
+	"TestTask/internal/repository"
 	"TestTask/internal/routes"
+	"TestTask/internal/service"
+	"TestTask/pkg/enrich"
 	"TestTask/pkg/logger"
+	"context"
 	"flag"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 // @title 			Test Task
@@ -16,16 +28,108 @@ import (
 // @host            localhost:8080
 // @BasePath        /
 
+// @securityDefinitions.apikey  AdminToken
+// @in                          header
+// @name                        Authorization
+// @description                 Bearer токен из admin.token, например "Bearer s3cret"
+
 func main() {
-	logger.InitLog()
-	config.LoadEnv()
-	database.ConnectToDB()
-	database.SyncDB()
-	mux := routes.SetupRoutes()
-	addr := flag.String("addr", ":8080", "http network addr")
-	logger.Logger.Println("Server starting at", *addr)
-	err := http.ListenAndServe(*addr, mux)
+	flags := config.BindFlags(flag.CommandLine)
+	flag.Parse()
+	cfg, err := flags.Load()
+	if err != nil {
+		logger.Fatal("Invalid configuration", "err", err)
+	}
+	if err := logger.Init(os.Stdout, cfg.Log.Format, cfg.Log.Level); err != nil {
+		logger.Fatal("Invalid log config", "err", err)
+	}
+	database.ConnectToDB(cfg)
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		logger.Fatal("Failed to connect to DB", "err", err)
+	}
+	if cfg.Database.AutoMigrate {
+		if err := database.Migrate(context.Background()); err != nil {
+			logger.Fatal("Migration failed", "err", err)
+		}
+	}
+
+	// ctx is cancelled on SIGINT or SIGTERM, which starts the graceful shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	users := repository.NewGormUserRepository(database.DB)
+	jobs := repository.NewGormJobRepository(database.DB)
+	e, err := enrich.New(cfg, database.DB)
 	if err != nil {
-		logger.Logger.Fatal("Could not start a server!", err)
+		logger.Fatal("Could not configure enrichment", "err", err)
+	}
+	enricher := enrich.NewReloadable(e)
+	reloader := reload.New(flags.Load, enricher, cfg, logger.Logger)
+	reloader.Watch(ctx, flags.Path(), cfg.Reload.WatchInterval)
+
+	// Without the workers users are enriched synchronously and queue stays nil.
+	var workers *enrichment.Pool
+	var queue service.Queue
+	if cfg.Async.Enabled {
+		workers = enrichment.StartWorkers(enrichment.Options{
+			Workers:      cfg.Async.Workers,
+			Attempts:     cfg.Async.Attempts,
+			Backoff:      cfg.Async.Backoff,
+			MaxBackoff:   cfg.Async.MaxBackoff,
+			PollInterval: cfg.Async.PollInterval,
+			LockTimeout:  cfg.Async.LockTimeout,
+			Jobs:         jobs,
+			Users:        users,
+			Enricher:     enricher,
+			Log:          logger.Logger,
+		})
+		queue = workers
+	}
+	svc := service.NewUserService(users, enricher, queue, logger.Logger)
+	svc.StartPurger(cfg.Retention.PurgeAfter, cfg.Retention.PurgeInterval)
+	mux := routes.SetupRoutes(v1.NewHandler(svc, logger.Logger, cfg.API.RequireIfMatch), handler.NewJobsHandler(jobs, workers, logger.Logger), enricher, reloader, sqlDB, cfg.Admin.Token)
+	srv := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           routes.LimitBody(mux, int64(cfg.Server.MaxBodyBytes), int64(cfg.Server.MaxImportBytes)),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.Logger.Handler(), slog.LevelWarn),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Logger.Info("Server starting", "addr", cfg.Server.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-serveErr:
+		logger.Fatal("Could not start the server", "err", err)
+	case <-ctx.Done():
+	}
+	// A second signal kills the process right away.
+	stop()
+
+	// Stop accepting requests and let the ones in flight, such as creates waiting for their
+	// enrichment, finish; then drain the enrichment workers within the same deadline.
+	logger.Logger.Info("Shutting down", "timeout", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Logger.Warn("Requests still running at the shutdown deadline, closing their connections", "err", err)
+		srv.Close()
+	}
+	if workers != nil {
+		if err := workers.Shutdown(shutdownCtx); err != nil {
+			logger.Logger.Warn("Enrichment jobs interrupted at the shutdown deadline, they are retried after the lock timeout", "err", err)
+		}
+	}
+	svc.StopPurger()
+	if err := sqlDB.Close(); err != nil {
+		logger.Logger.Error("Could not close the DB connections", "err", err)
 	}
+	logger.Logger.Info("Server stopped")
 }
@@ -0,0 +1,103 @@
+// Command migrate applies, rolls back or lists the database schema migrations.
+//
+// Usage:
+//
+//	go run ./cmd/migrate up
+//	go run ./cmd/migrate down -steps 2
+//	go run ./cmd/migrate status
+package main
+
+import (
+	"TestTask/internal/config"
+	"TestTask/internal/database"
+	"TestTask/pkg/logger"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Usage: migrate up|down|status [flags]
+
+  up      apply every pending migration
+  down    roll back the latest migrations, one unless -steps says otherwise
+  status  list the migrations and when they were applied
+
+Flags:
+`
+
+func main() {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	steps := fs.Int("steps", 1, "number of migrations rolled back by down")
+	flags := config.BindFlags(fs)
+	if len(os.Args) < 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	command := os.Args[1]
+	fs.Parse(os.Args[2:])
+	if command != "up" && command != "down" && command != "status" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *steps <= 0 {
+		logger.Fatal("-steps must be positive")
+	}
+
+	cfg, err := flags.Load()
+	if err != nil {
+		logger.Fatal("Invalid configuration", "err", err)
+	}
+	if err := logger.Init(os.Stderr, cfg.Log.Format, cfg.Log.Level); err != nil {
+		logger.Fatal("Invalid log config", "err", err)
+	}
+	database.ConnectToDB(cfg)
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		logger.Fatal("Failed to connect to DB", "err", err)
+	}
+	m, err := database.NewMigrator(sqlDB, logger.Logger)
+	if err != nil {
+		logger.Fatal("Invalid migrations", "err", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch command {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			logger.Fatal("Migration failed", "err", err)
+		}
+		logger.Logger.Info("Migrations applied", "count", len(applied))
+	case "down":
+		rolledBack, err := m.Down(ctx, *steps)
+		if err != nil {
+			logger.Fatal("Rollback failed", "err", err)
+		}
+		logger.Logger.Info("Migrations rolled back", "count", len(rolledBack))
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			logger.Fatal("Could not read the migration status", "err", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Local().Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", st.Version, st.Name, applied)
+		}
+		tw.Flush()
+	}
+}
@@ -15,35 +15,127 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/createuser": {
+        "/admin/config/reload": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Результат последней перезагрузки настроек обогащения (по SIGHUP, изменению файла или запросу)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Статус перезагрузки конфигурации",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reload.Status"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or missing admin token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints are only served to localhost",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Добавить нового пользователя и обогатить его данными",
-                "consumes": [
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Перечитать конфигурацию и применить настройки обогащения без перезапуска. Некорректная конфигурация не применяется.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Перезагрузка конфигурации",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reload.Status"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or missing admin token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints are only served to localhost",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid configuration, current settings kept",
+                        "schema": {
+                            "$ref": "#/definitions/reload.Status"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Получить задачи обогащения, новые первыми, с фильтром по статусу",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Создание пользователя",
+                "summary": "Список задач обогащения",
                 "parameters": [
                     {
-                        "description": "User Data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.User"
-                        }
+                        "type": "string",
+                        "description": "Статус (queued, running, succeeded, dead, cancelled)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество на странице (не больше 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EnrichmentJob"
+                            }
                         }
                     },
                     "400": {
@@ -52,6 +144,18 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Invalid or missing admin token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints are only served to localhost",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -61,33 +165,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/deleteuser": {
-            "delete": {
-                "description": "Удалить пользователя по ID",
-                "consumes": [
-                    "application/json"
+        "/admin/jobs/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
                 ],
+                "description": "Отменить задачу, ожидающую в очереди",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Удаление пользователя",
+                "summary": "Отмена задачи обогащения",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
+                        "description": "ID задачи",
                         "name": "id",
-                        "in": "query",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User deleted",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.EnrichmentJob"
                         }
                     },
                     "400": {
@@ -96,8 +202,26 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Invalid or missing admin token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints are only served to localhost",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": "User not found",
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Job cannot be cancelled",
                         "schema": {
                             "type": "string"
                         }
@@ -105,42 +229,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/updateuser": {
-            "put": {
-                "description": "Обновить пользователя по ID",
-                "consumes": [
-                    "application/json"
+        "/admin/jobs/{id}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
                 ],
+                "description": "Вернуть мёртвую или отменённую задачу в очередь с новым запасом попыток",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Обновление пользователя",
+                "summary": "Повтор задачи обогащения",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "user id",
+                        "description": "ID задачи",
                         "name": "id",
-                        "in": "query",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "updated data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.User"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.EnrichmentJob"
                         }
                     },
                     "400": {
@@ -149,8 +266,26 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Invalid or missing admin token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints are only served to localhost",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Job cannot be retried",
                         "schema": {
                             "type": "string"
                         }
@@ -158,9 +293,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user": {
+        "/api/v1/users": {
             "get": {
-                "description": "Получить список пользователей с фильтрами и пагинацией",
+                "description": "Получить список пользователей с фильтрами, сортировкой и пагинацией. Некорректные параметры возвращают 400.\nСсылка на следующую страницу передаётся в заголовке Link (rel=\"next\"). С envelope=true ответ — объект v1.UserPage с data и page_info.",
                 "consumes": [
                     "application/json"
                 ],
@@ -172,18 +307,60 @@ const docTemplate = `{
                 ],
                 "summary": "Получение пользователей",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из заголовка Link или page_info.next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Номер страницы",
+                        "description": "Номер страницы (вместо cursor)",
                         "name": "page",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Количество на странице",
+                        "description": "Количество на странице, не больше 100 (по умолчанию 10)",
                         "name": "limit",
                         "in": "query"
                     },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть общее количество в X-Total-Count (и page_info.total)",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть v1.UserPage вместо массива",
+                        "name": "envelope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка через запятую, - для убывания: id, name, surname, age, gender, nationality, created_at, updated_at (например -age,surname)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя начинается с (без учёта регистра)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фамилия начинается с (без учёта регистра)",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Нечёткий поиск по имени и фамилии (pg_trgm)",
+                        "name": "q",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Мин. возраст",
@@ -198,15 +375,75 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Пол",
+                        "description": "Пол (male, female)",
                         "name": "gender",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Национальность",
+                        "description": "Национальности через запятую (например RU,UA)",
                         "name": "nationality",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы не раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменены не раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменены раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "updated_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Мин. вероятность пола",
+                        "name": "gender_probability_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Мин. вероятность национальности",
+                        "name": "nationality_probability_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только без национальности",
+                        "name": "nationality_missing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Статус обогащения (pending, succeeded, failed)",
+                        "name": "enrichment_status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Обогащены раньше даты (RFC 3339 или YYYY-MM-DD)",
+                        "name": "enriched_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Включая удалённых",
+                        "name": "include_deleted",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -215,52 +452,1240 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.User"
+                                "$ref": "#/definitions/v1.User"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Следующая страница, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "int",
+                                "description": "Общее количество, если count=true"
                             }
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid filter, sort or pagination",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "models.User": {
-            "type": "object",
-            "properties": {
-                "age": {
-                    "type": "integer"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
-                "deletedAt": {
-                    "type": "string"
-                },
-                "gender": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "nationality": {
-                    "type": "string"
-                },
-                "surname": {
-                    "type": "string"
-                },
-                "updatedAt": {
-                    "type": "string"
-                }
-            }
+            },
+            "post": {
+                "description": "Добавить нового пользователя и обогатить его данными",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Создание пользователя",
+                "parameters": [
+                    {
+                        "description": "User Data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/v1.User"
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted, enrichment pending",
+                        "schema": {
+                            "$ref": "#/definitions/v1.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Enrichment service unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/export": {
+            "get": {
+                "description": "Потоковая выгрузка всех пользователей по тем же фильтрам, что и GET /api/v1/users, в CSV или NDJSON",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Выгрузка пользователей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Формат (csv, ndjson), по умолчанию ndjson",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Мин. возраст",
+                        "name": "age_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Макс. возраст",
+                        "name": "age_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Пол (male, female)",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Национальности через запятую (например RU,UA)",
+                        "name": "nationality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя начинается с (без учёта регистра)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фамилия начинается с (без учёта регистра)",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Нечёткий поиск по имени и фамилии (pg_trgm)",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы не раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменены не раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменены раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "updated_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Мин. вероятность пола",
+                        "name": "gender_probability_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Мин. вероятность национальности",
+                        "name": "nationality_probability_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только без национальности",
+                        "name": "nationality_missing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Статус обогащения (pending, succeeded, failed)",
+                        "name": "enrichment_status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Обогащены раньше даты (RFC 3339 или YYYY-MM-DD)",
+                        "name": "enriched_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Включая удалённых",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Users, one per line",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/import": {
+            "post": {
+                "description": "Импортировать пользователей из CSV (колонки name, surname) или NDJSON, обогатить и сохранить пакетами",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Массовый импорт пользователей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Формат (csv, ndjson); по умолчанию определяется по Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/importer.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Body over server.max_import_bytes; the rows before the limit are imported",
+                        "schema": {
+                            "$ref": "#/definitions/importer.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/stats": {
+            "get": {
+                "description": "Количество по полу, национальности и возрастным интервалам, средний и медианный возраст по национальностям и покрытие обогащением.\nУчитывает те же фильтры, что и GET /api/v1/users. Пользователи с неизвестным возрастом (0) не входят в возрастные интервалы и средние.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Статистика пользователей",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ширина возрастного интервала в годах, от 1 до 100 (по умолчанию 10)",
+                        "name": "bucket_width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя начинается с (без учёта регистра)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фамилия начинается с (без учёта регистра)",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Нечёткий поиск по имени и фамилии (pg_trgm)",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Мин. возраст",
+                        "name": "age_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Макс. возраст",
+                        "name": "age_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Пол (male, female)",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Национальности через запятую (например RU,UA)",
+                        "name": "nationality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы не раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Созданы раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменены не раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "updated_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменены раньше (RFC 3339 или YYYY-MM-DD)",
+                        "name": "updated_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Мин. вероятность пола",
+                        "name": "gender_probability_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Мин. вероятность национальности",
+                        "name": "nationality_probability_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только без национальности",
+                        "name": "nationality_missing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Статус обогащения (pending, succeeded, failed)",
+                        "name": "enrichment_status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Обогащены раньше даты (RFC 3339 или YYYY-MM-DD)",
+                        "name": "enriched_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Включая удалённых",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Stats"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}": {
+            "get": {
+                "description": "Получить пользователя по ID вместе со статусом обогащения. Версия пользователя возвращается в заголовке ETag.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получение пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag закэшированной версии",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия пользователя"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновить пользователя по ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Обновление пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag изменяемой версии",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "updated data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия пользователя"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "User was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удалить пользователя по ID. Удалённого пользователя можно восстановить, пока он не стёрт окончательно.\nС purge=true пользователь и данные обогащения стираются сразу и безвозвратно.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Удаление пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Стереть безвозвратно",
+                        "name": "purge",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag удаляемой версии",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User deleted",
+                        "schema": {
+                            "$ref": "#/definitions/v1.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "User was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Изменить только переданные поля. application/merge-patch+json (RFC 7396, по умолчанию): отсутствующие поля не меняются, null сбрасывает поле.\napplication/json-patch+json (RFC 6902): операции add, remove, replace, move, copy, test над путями /Name, /Surname, /Age, /Gender, /Nationality.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Частичное обновление пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag изменяемой версии",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Merge patch с изменяемыми полями или массив операций JSON Patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия пользователя"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "JSON Patch test failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "User was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported patch format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/enrich": {
+            "post": {
+                "description": "Заново получить возраст, пол и национальность пользователя в обход кэша",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Повторное обогащение пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Enrichment service unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/restore": {
+            "post": {
+                "description": "Восстановить удалённого пользователя, если он ещё не стёрт",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Восстановление пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "User is not deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/enrichment/cache": {
+            "get": {
+                "description": "Количество попаданий и промахов кэша обогащения с момента запуска",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "enrichment"
+                ],
+                "summary": "Статистика кэша обогащения",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/enrich.CacheStats"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Статистика пула соединений с базой данных в текстовом формате Prometheus",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Метрики",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "200, если база данных отвечает, иначе 503. Для readiness-проб балансировщика или Kubernetes.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка готовности",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "database unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "enrich.CacheStats": {
+            "type": "object",
+            "properties": {
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "importer.Report": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/importer.RowResult"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "importer.RowResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "surname": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.EnrichmentJob": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "lockedAt": {
+                    "type": "string"
+                },
+                "maxAttempts": {
+                    "type": "integer"
+                },
+                "nextRunAt": {
+                    "type": "string"
+                },
+                "requestID": {
+                    "description": "RequestID is the ID of the API request that queued the job, for correlating the worker logs.",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "reload.Status": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "loaded_at": {
+                    "type": "string"
+                },
+                "reloads": {
+                    "description": "Reloads counts the successful reloads since startup; LoadedAt is when the active settings were applied.",
+                    "type": "integer"
+                },
+                "restart_required": {
+                    "description": "RestartRequired lists the changed sections a reload cannot apply.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "trigger": {
+                    "description": "Trigger, At, Success and Error describe the last attempt; they are empty before the first one.",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.AgeBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.Coverage": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "succeeded": {
+                    "type": "integer"
+                },
+                "with_age": {
+                    "type": "integer"
+                },
+                "with_gender": {
+                    "type": "integer"
+                },
+                "with_metadata": {
+                    "type": "integer"
+                },
+                "with_nationality": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.CreateUserRequest": {
+            "type": "object",
+            "properties": {
+                "Name": {
+                    "type": "string"
+                },
+                "Surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.Enrichment": {
+            "type": "object",
+            "properties": {
+                "AgeCount": {
+                    "type": "integer"
+                },
+                "AgeProvider": {
+                    "type": "string"
+                },
+                "CreatedAt": {
+                    "type": "string"
+                },
+                "EnrichedAt": {
+                    "type": "string"
+                },
+                "GenderCount": {
+                    "type": "integer"
+                },
+                "GenderProbability": {
+                    "type": "number"
+                },
+                "GenderProvider": {
+                    "type": "string"
+                },
+                "ID": {
+                    "type": "integer"
+                },
+                "NationalityCandidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.NationalityCandidate"
+                    }
+                },
+                "NationalityCount": {
+                    "type": "integer"
+                },
+                "NationalityProbability": {
+                    "type": "number"
+                },
+                "NationalityProvider": {
+                    "type": "string"
+                },
+                "UpdatedAt": {
+                    "type": "string"
+                },
+                "UserID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.GenderCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "gender": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.NationalityCandidate": {
+            "type": "object",
+            "properties": {
+                "CountryID": {
+                    "type": "string"
+                },
+                "Probability": {
+                    "type": "number"
+                }
+            }
+        },
+        "v1.NationalityStats": {
+            "type": "object",
+            "properties": {
+                "average_age": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "median_age": {
+                    "type": "number"
+                },
+                "nationality": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.Stats": {
+            "type": "object",
+            "properties": {
+                "age_buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.AgeBucket"
+                    }
+                },
+                "by_gender": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.GenderCount"
+                    }
+                },
+                "by_nationality": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.NationalityStats"
+                    }
+                },
+                "coverage": {
+                    "$ref": "#/definitions/v1.Coverage"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "Age": {
+                    "type": "integer"
+                },
+                "Gender": {
+                    "type": "string"
+                },
+                "Name": {
+                    "type": "string"
+                },
+                "Nationality": {
+                    "type": "string"
+                },
+                "Surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.User": {
+            "type": "object",
+            "properties": {
+                "Age": {
+                    "type": "integer"
+                },
+                "CreatedAt": {
+                    "type": "string"
+                },
+                "DeletedAt": {
+                    "type": "string"
+                },
+                "Enrichment": {
+                    "$ref": "#/definitions/v1.Enrichment"
+                },
+                "EnrichmentError": {
+                    "type": "string"
+                },
+                "EnrichmentStatus": {
+                    "type": "string"
+                },
+                "Gender": {
+                    "type": "string"
+                },
+                "ID": {
+                    "type": "integer"
+                },
+                "Name": {
+                    "type": "string"
+                },
+                "Nationality": {
+                    "type": "string"
+                },
+                "Surname": {
+                    "type": "string"
+                },
+                "UpdatedAt": {
+                    "type": "string"
+                },
+                "Version": {
+                    "type": "integer"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Bearer токен из admin.token, например \"Bearer s3cret\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,130 @@
+// Package query reads URL query parameters for the API handlers. Malformed values are
+// reported instead of being ignored, so a typo does not silently widen a result.
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseTime accepts either an RFC 3339 timestamp or a YYYY-MM-DD date.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// Parser converts query parameters and keeps the first conversion error.
+// Missing parameters are not an error.
+type Parser struct {
+	q   url.Values
+	err error
+}
+
+// NewParser returns a Parser reading q.
+func NewParser(q url.Values) *Parser {
+	return &Parser{q: q}
+}
+
+// Err returns the first malformed parameter.
+func (p *Parser) Err() error {
+	return p.err
+}
+
+func (p *Parser) fail(name, value, want string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("invalid %s %q: must be %s", name, value, want)
+	}
+}
+
+// Int reads a non-negative integer.
+func (p *Parser) Int(name string) *int {
+	raw := p.q.Get(name)
+	if raw == "" {
+		return nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		p.fail(name, raw, "a non-negative integer")
+		return nil
+	}
+	return &v
+}
+
+// Probability reads a number between 0 and 1.
+func (p *Parser) Probability(name string) *float64 {
+	raw := p.q.Get(name)
+	if raw == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 || v > 1 {
+		p.fail(name, raw, "a number between 0 and 1")
+		return nil
+	}
+	return &v
+}
+
+// Bool reads true or false, false when missing.
+func (p *Parser) Bool(name string) bool {
+	raw := p.q.Get(name)
+	if raw == "" {
+		return false
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		p.fail(name, raw, "true or false")
+	}
+	return v
+}
+
+// Time reads a timestamp in one of the formats of ParseTime.
+func (p *Parser) Time(name string) *time.Time {
+	raw := p.q.Get(name)
+	if raw == "" {
+		return nil
+	}
+	v, err := ParseTime(raw)
+	if err != nil {
+		p.fail(name, raw, "an RFC 3339 timestamp or YYYY-MM-DD date")
+		return nil
+	}
+	return &v
+}
+
+// OneOf reads a value that must be one of allowed.
+func (p *Parser) OneOf(name string, allowed ...string) string {
+	raw := p.q.Get(name)
+	if raw == "" {
+		return ""
+	}
+	for _, a := range allowed {
+		if raw == a {
+			return raw
+		}
+	}
+	p.fail(name, raw, "one of "+strings.Join(allowed, ", "))
+	return ""
+}
+
+// Countries reads a comma-separated list of ISO 3166-1 alpha-2 codes, e.g. "RU,UA".
+func (p *Parser) Countries(name string) []string {
+	raw := p.q.Get(name)
+	if raw == "" {
+		return nil
+	}
+	var codes []string
+	for _, code := range strings.Split(raw, ",") {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if len(code) != 2 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			p.fail(name, raw, "a comma-separated list of two-letter country codes")
+			return nil
+		}
+		codes = append(codes, code)
+	}
+	return codes
+}
@@ -0,0 +1,196 @@
+package v1
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"time"
+)
+
+// The v1 DTOs freeze the JSON contract of the first API version. Field names match the
+// way models.User was serialized before versioning, so the model can evolve independently.
+
+// User is the v1 representation of a user.
+type User struct {
+	ID               uint        `json:"ID"`
+	CreatedAt        time.Time   `json:"CreatedAt"`
+	UpdatedAt        time.Time   `json:"UpdatedAt"`
+	DeletedAt        *time.Time  `json:"DeletedAt"`
+	Name             string      `json:"Name"`
+	Surname          string      `json:"Surname"`
+	Age              int         `json:"Age"`
+	Gender           string      `json:"Gender"`
+	Nationality      string      `json:"Nationality"`
+	Enrichment       *Enrichment `json:"Enrichment"`
+	EnrichmentStatus string      `json:"EnrichmentStatus"`
+	EnrichmentError  string      `json:"EnrichmentError"`
+	Version          uint        `json:"Version"`
+}
+
+// Enrichment is the confidence and provenance of a user's enriched attributes.
+type Enrichment struct {
+	ID                     uint                   `json:"ID"`
+	CreatedAt              time.Time              `json:"CreatedAt"`
+	UpdatedAt              time.Time              `json:"UpdatedAt"`
+	UserID                 uint                   `json:"UserID"`
+	AgeProvider            string                 `json:"AgeProvider"`
+	AgeCount               int                    `json:"AgeCount"`
+	GenderProvider         string                 `json:"GenderProvider"`
+	GenderProbability      float64                `json:"GenderProbability"`
+	GenderCount            int                    `json:"GenderCount"`
+	NationalityProvider    string                 `json:"NationalityProvider"`
+	NationalityProbability float64                `json:"NationalityProbability"`
+	NationalityCount       int                    `json:"NationalityCount"`
+	NationalityCandidates  []NationalityCandidate `json:"NationalityCandidates"`
+	EnrichedAt             time.Time              `json:"EnrichedAt"`
+}
+
+// NationalityCandidate is one of the ranked countries returned by the nationality provider.
+type NationalityCandidate struct {
+	CountryID   string  `json:"CountryID"`
+	Probability float64 `json:"Probability"`
+}
+
+// CreateUserRequest is the body of POST /api/v1/users.
+type CreateUserRequest struct {
+	Name    string `json:"Name"`
+	Surname string `json:"Surname"`
+}
+
+// UpdateUserRequest is the body of PUT /api/v1/users/{id}.
+type UpdateUserRequest struct {
+	Name        string `json:"Name"`
+	Surname     string `json:"Surname"`
+	Age         int    `json:"Age"`
+	Gender      string `json:"Gender"`
+	Nationality string `json:"Nationality"`
+}
+
+// UserPage is one page of the users list.
+type UserPage struct {
+	Data     []User   `json:"data"`
+	PageInfo PageInfo `json:"page_info"`
+}
+
+// PageInfo tells how to get the next page. NextCursor is empty on the last page and when
+// results are ranked by search relevance, which can only be paged with page numbers.
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// Stats are aggregates over the users matching the list filters. Users with an unknown
+// age (0) are left out of the age buckets and the average and median ages.
+type Stats struct {
+	Total         int64              `json:"total"`
+	ByGender      []GenderCount      `json:"by_gender"`
+	ByNationality []NationalityStats `json:"by_nationality"`
+	AgeBuckets    []AgeBucket        `json:"age_buckets"`
+	Coverage      Coverage           `json:"coverage"`
+}
+
+type GenderCount struct {
+	Gender string `json:"gender"`
+	Count  int64  `json:"count"`
+}
+
+type NationalityStats struct {
+	Nationality string   `json:"nationality"`
+	Count       int64    `json:"count"`
+	AverageAge  *float64 `json:"average_age"`
+	MedianAge   *float64 `json:"median_age"`
+}
+
+// AgeBucket counts the users aged from to to, inclusive.
+type AgeBucket struct {
+	From  int   `json:"from"`
+	To    int   `json:"to"`
+	Count int64 `json:"count"`
+}
+
+// Coverage counts users by enrichment status and by known attributes.
+type Coverage struct {
+	Succeeded       int64 `json:"succeeded"`
+	Pending         int64 `json:"pending"`
+	Failed          int64 `json:"failed"`
+	WithAge         int64 `json:"with_age"`
+	WithGender      int64 `json:"with_gender"`
+	WithNationality int64 `json:"with_nationality"`
+	WithMetadata    int64 `json:"with_metadata"`
+}
+
+// MessageResponse carries a human-readable outcome.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+func newUser(u *models.User) User {
+	res := User{
+		ID:               u.ID,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+		Name:             u.Name,
+		Surname:          u.Surname,
+		Age:              u.Age,
+		Gender:           u.Gender,
+		Nationality:      u.Nationality,
+		EnrichmentStatus: u.EnrichmentStatus,
+		EnrichmentError:  u.EnrichmentError,
+		Version:          u.Version,
+	}
+	if u.DeletedAt.Valid {
+		res.DeletedAt = &u.DeletedAt.Time
+	}
+	if e := u.Enrichment; e != nil {
+		res.Enrichment = &Enrichment{
+			ID:                     e.ID,
+			CreatedAt:              e.CreatedAt,
+			UpdatedAt:              e.UpdatedAt,
+			UserID:                 e.UserID,
+			AgeProvider:            e.AgeProvider,
+			AgeCount:               e.AgeCount,
+			GenderProvider:         e.GenderProvider,
+			GenderProbability:      e.GenderProbability,
+			GenderCount:            e.GenderCount,
+			NationalityProvider:    e.NationalityProvider,
+			NationalityProbability: e.NationalityProbability,
+			NationalityCount:       e.NationalityCount,
+			EnrichedAt:             e.EnrichedAt,
+		}
+		for _, c := range e.NationalityCandidates {
+			res.Enrichment.NationalityCandidates = append(res.Enrichment.NationalityCandidates,
+				NationalityCandidate{CountryID: c.CountryID, Probability: c.Probability})
+		}
+	}
+	return res
+}
+
+func newUsers(users []models.User) []User {
+	res := make([]User, 0, len(users))
+	for i := range users {
+		res = append(res, newUser(&users[i]))
+	}
+	return res
+}
+
+func newStats(s *repository.UserStats) Stats {
+	res := Stats{
+		Total:         s.Total,
+		ByGender:      make([]GenderCount, 0, len(s.ByGender)),
+		ByNationality: make([]NationalityStats, 0, len(s.ByNationality)),
+		AgeBuckets:    make([]AgeBucket, 0, len(s.AgeBuckets)),
+		Coverage:      Coverage(s.Coverage),
+	}
+	for _, g := range s.ByGender {
+		res.ByGender = append(res.ByGender, GenderCount(g))
+	}
+	for _, n := range s.ByNationality {
+		res.ByNationality = append(res.ByNationality, NationalityStats(n))
+	}
+	for _, b := range s.AgeBuckets {
+		res.AgeBuckets = append(res.AgeBuckets, AgeBucket(b))
+	}
+	return res
+}
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"TestTask/internal/models"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// etag is the strong entity tag of a user, derived from its version.
+func etag(user *models.User) string {
+	return `"` + strconv.FormatUint(uint64(user.Version), 10) + `"`
+}
+
+// setETag sets the ETag header of a response representing user.
+func setETag(w http.ResponseWriter, user *models.User) {
+	w.Header().Set("ETag", etag(user))
+}
+
+// ifMatch returns the user version required by the If-Match header of r,
+// or 0 for an unconditional request (no header or "*"). When it returns false
+// the request failed the precondition and the error response has been written.
+func (h *Handler) ifMatch(w http.ResponseWriter, r *http.Request, id int) (uint, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if h.requireIfMatch {
+			h.log.WarnContext(r.Context(), "Missing If-Match", "user_id", id)
+			http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+			return 0, false
+		}
+		return 0, true
+	}
+	if strings.TrimSpace(header) == "*" {
+		return 0, true
+	}
+
+	// If-Match uses the strong comparison, so weak tags never match.
+	var versions []uint
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if v, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 0); err == nil && v > 0 {
+			versions = append(versions, uint(v))
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+	case 1:
+		return versions[0], true
+	default:
+		user, err := h.users.GetUser(r.Context(), id)
+		if err != nil {
+			// Let the write itself report the missing user.
+			return versions[0], true
+		}
+		if slices.Contains(versions, user.Version) {
+			return user.Version, true
+		}
+	}
+	h.preconditionFailed(w, r, id)
+	return 0, false
+}
+
+// ifNoneMatch reports whether the If-None-Match header of r matches the current ETag of user.
+func ifNoneMatch(r *http.Request, user *models.User) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	current := etag(user)
+	for _, tag := range strings.Split(header, ",") {
+		// If-None-Match uses the weak comparison.
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) preconditionFailed(w http.ResponseWriter, r *http.Request, id int) {
+	h.log.WarnContext(r.Context(), "Precondition failed", "user_id", id)
+	http.Error(w, fmt.Sprintf("User %d has been modified, reload it and retry", id), http.StatusPreconditionFailed)
+}
@@ -0,0 +1,131 @@
+package v1
+
+import (
+	"TestTask/internal/models"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// flushEvery is how many exported rows are buffered before they are flushed to the client.
+const flushEvery = 500
+
+var exportColumns = []string{
+	"id", "name", "surname", "age", "gender", "nationality",
+	"enrichment_status", "created_at", "updated_at", "deleted_at",
+}
+
+// ExportUsers godoc
+// @Summary      Выгрузка пользователей
+// @Description  Потоковая выгрузка всех пользователей по тем же фильтрам, что и GET /api/v1/users, в CSV или NDJSON
+// @Tags         users
+// @Produce      text/csv,application/x-ndjson
+// @Param        format      query   string  false  "Формат (csv, ndjson), по умолчанию ndjson"
+// @Param        age_min     query   int     false  "Мин. возраст"
+// @Param        age_max     query   int     false  "Макс. возраст"
+// @Param        gender      query   string  false  "Пол (male, female)"
+// @Param        nationality query   string  false  "Национальности через запятую (например RU,UA)"
+// @Param        name        query   string  false  "Имя начинается с (без учёта регистра)"
+// @Param        surname     query   string  false  "Фамилия начинается с (без учёта регистра)"
+// @Param        q           query   string  false  "Нечёткий поиск по имени и фамилии (pg_trgm)"
+// @Param        created_after   query  string  false  "Созданы не раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        created_before  query  string  false  "Созданы раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        updated_after   query  string  false  "Изменены не раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        updated_before  query  string  false  "Изменены раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        gender_probability_min      query  number  false  "Мин. вероятность пола"
+// @Param        nationality_probability_min query  number  false  "Мин. вероятность национальности"
+// @Param        nationality_missing query  bool    false  "Только без национальности"
+// @Param        enrichment_status   query  string  false  "Статус обогащения (pending, succeeded, failed)"
+// @Param        enriched_before     query  string  false  "Обогащены раньше даты (RFC 3339 или YYYY-MM-DD)"
+// @Param        include_deleted     query  bool    false  "Включая удалённых"
+// @Success      200  {string}  string "Users, one per line"
+// @Failure      400  {string}  string "Bad request"
+// @Router       /api/v1/users/export [get]
+func (h *Handler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		h.log.WarnContext(r.Context(), "Invalid filter", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+
+	var write func(user *models.User) error
+	var flush func() error
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		write = func(user *models.User) error {
+			var deletedAt string
+			if user.DeletedAt.Valid {
+				deletedAt = user.DeletedAt.Time.Format(time.RFC3339)
+			}
+			return cw.Write([]string{
+				strconv.FormatUint(uint64(user.ID), 10),
+				user.Name,
+				user.Surname,
+				strconv.Itoa(user.Age),
+				user.Gender,
+				user.Nationality,
+				user.EnrichmentStatus,
+				user.CreatedAt.Format(time.RFC3339),
+				user.UpdatedAt.Format(time.RFC3339),
+				deletedAt,
+			})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+		if err := cw.Write(exportColumns); err != nil {
+			h.log.ErrorContext(r.Context(), "Could not write export header", "err", err)
+			return
+		}
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		write = func(user *models.User) error { return enc.Encode(newUser(user)) }
+		flush = func() error { return nil }
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="users.ndjson"`)
+	default:
+		h.log.WarnContext(r.Context(), "Unsupported export format", "format", format)
+		http.Error(w, "Unsupported format, use csv or ndjson", http.StatusBadRequest)
+		return
+	}
+
+	// The stream may run longer than the server's write timeout allows for a whole response.
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	flusher, _ := w.(http.Flusher)
+	count := 0
+	err = h.users.StreamUsers(r.Context(), filter, func(user *models.User) error {
+		if err := write(user); err != nil {
+			return err
+		}
+		count++
+		if count%flushEvery == 0 && flusher != nil {
+			if err := flush(); err != nil {
+				return err
+			}
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		// The status line is already sent, so the client only sees a truncated body.
+		h.log.ErrorContext(r.Context(), "Export aborted", "exported", count, "err", err)
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Users exported", "exported", count, "format", format)
+}
@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"TestTask/internal/api/query"
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// parseUserFilter reads the list filters shared by GET /api/v1/users and the export endpoint.
+// Malformed values are reported instead of being ignored.
+func parseUserFilter(q url.Values) (repository.UserFilter, error) {
+	p := query.NewParser(q)
+	filter := repository.UserFilter{
+		Gender:             q.Get("gender"),
+		Name:               strings.TrimSpace(q.Get("name")),
+		Surname:            strings.TrimSpace(q.Get("surname")),
+		Search:             strings.TrimSpace(q.Get("q")),
+		EnrichmentStatus:   q.Get("enrichment_status"),
+		Nationalities:      p.Countries("nationality"),
+		AgeMin:             p.Int("age_min"),
+		AgeMax:             p.Int("age_max"),
+		NationalityMissing: p.Bool("nationality_missing"),
+		IncludeDeleted:     p.Bool("include_deleted"),
+		CreatedAfter:       p.Time("created_after"),
+		CreatedBefore:      p.Time("created_before"),
+		UpdatedAfter:       p.Time("updated_after"),
+		UpdatedBefore:      p.Time("updated_before"),
+		EnrichedBefore:     p.Time("enriched_before"),
+
+		GenderProbabilityMin:      p.Probability("gender_probability_min"),
+		NationalityProbabilityMin: p.Probability("nationality_probability_min"),
+	}
+	if p.Err() != nil {
+		return filter, p.Err()
+	}
+
+	switch filter.EnrichmentStatus {
+	case "", models.EnrichmentPending, models.EnrichmentSucceeded, models.EnrichmentFailed:
+	default:
+		return filter, fmt.Errorf("enrichment_status must be one of %s, %s, %s",
+			models.EnrichmentPending, models.EnrichmentSucceeded, models.EnrichmentFailed)
+	}
+	if !models.ValidGender(filter.Gender) {
+		return filter, fmt.Errorf("gender must be %s or %s", models.GenderMale, models.GenderFemale)
+	}
+	if filter.AgeMin != nil && filter.AgeMax != nil && *filter.AgeMin > *filter.AgeMax {
+		return filter, fmt.Errorf("age_min must not be greater than age_max")
+	}
+	return filter, nil
+}
+
+// Page sizes of the users list.
+const (
+	defaultLimit = 10
+	maxLimit     = 100
+)
+
+// parsePage reads the sort and pagination query parameters. The first page is returned
+// unless a cursor or a page number is given; page numbers are kept for existing clients.
+func parsePage(q url.Values) (req repository.PageRequest, page int, err error) {
+	p := query.NewParser(q)
+	req.Limit, page = defaultLimit, 1
+	if v := p.Int("limit"); v != nil {
+		req.Limit = *v
+	}
+	if v := p.Int("page"); v != nil {
+		page = *v
+	}
+	req.Cursor = q.Get("cursor")
+	req.Count = p.Bool("count")
+	switch {
+	case p.Err() != nil:
+		return req, 0, p.Err()
+	case page < 1:
+		return req, 0, fmt.Errorf("page must be positive")
+	case req.Limit < 1:
+		return req, 0, fmt.Errorf("limit must be positive")
+	case req.Cursor != "" && q.Has("page"):
+		return req, 0, fmt.Errorf("use either cursor or page")
+	}
+	// Larger pages are cut to the maximum rather than rejected, as v1 always accepted any limit.
+	req.Limit = min(req.Limit, maxLimit)
+	req.Offset = (page - 1) * req.Limit
+
+	req.Sorts, err = repository.ParseSort(q.Get("sort"))
+	return req, page, err
+}
@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"TestTask/internal/repository"
+	"net/url"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantLimit  int
+		wantOffset int
+		wantPage   int
+		wantErr    bool
+	}{
+		{"", 10, 0, 1, false},
+		{"page=3&limit=20", 20, 40, 3, false},
+		{"limit=500", 100, 0, 1, false},
+		{"page=2&limit=500", 100, 100, 2, false},
+		{"cursor=abc&limit=5", 5, 0, 1, false},
+		{"limit=0", 0, 0, 0, true},
+		{"limit=abc", 0, 0, 0, true},
+		{"page=0", 0, 0, 0, true},
+		{"cursor=abc&page=2", 0, 0, 0, true},
+		{"sort=password", 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			req, page, err := parsePage(q)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (req.Limit != tt.wantLimit || req.Offset != tt.wantOffset || page != tt.wantPage) {
+				t.Errorf("limit %d, offset %d, page %d; want %d, %d, %d", req.Limit, req.Offset, page, tt.wantLimit, tt.wantOffset, tt.wantPage)
+			}
+		})
+	}
+}
+
+func TestParseUserFilter(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{"", false},
+		{"gender=male", false},
+		{"gender=female&age_min=20&age_max=30", false},
+		{"gender=unknown", true},
+		{"gender=Male", true},
+		{"enrichment_status=done", true},
+		{"age_min=30&age_max=20", true},
+		{"age_min=abc", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			if _, err := parseUserFilter(q); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		req  repository.PageRequest
+		page int
+		info repository.PageInfo
+		want string
+	}{
+		{"last page", "/api/v1/users?limit=5", repository.PageRequest{}, 1,
+			repository.PageInfo{HasMore: false, NextCursor: "abc"}, ""},
+		{"cursor replaces page", "/api/v1/users?gender=male&page=2", repository.PageRequest{}, 2,
+			repository.PageInfo{HasMore: true, NextCursor: "abc"}, "/api/v1/users?cursor=abc&gender=male"},
+		{"next cursor", "/users?cursor=abc", repository.PageRequest{Cursor: "abc"}, 1,
+			repository.PageInfo{HasMore: true, NextCursor: "def"}, "/users?cursor=def"},
+		{"ranked results use page numbers", "/api/v1/users?q=dima&page=2", repository.PageRequest{}, 2,
+			repository.PageInfo{HasMore: true}, "/api/v1/users?page=3&q=dima"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			if got := nextPage(u, tt.req, tt.page, tt.info); got != tt.want {
+				t.Errorf("nextPage = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
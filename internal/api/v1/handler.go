@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"TestTask/internal/service"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// Handler serves the v1 user endpoints.
+type Handler struct {
+	users *service.UserService
+	log   *slog.Logger
+
+	// requireIfMatch makes PUT, PATCH and DELETE of a user answer 428 Precondition Required
+	// when the request has no If-Match header. Otherwise such requests overwrite unconditionally.
+	requireIfMatch bool
+}
+
+// NewHandler returns a Handler serving users from the given service and logging to log.
+func NewHandler(users *service.UserService, log *slog.Logger, requireIfMatch bool) *Handler {
+	return &Handler{users: users, log: log, requireIfMatch: requireIfMatch}
+}
+
+// tooLarge answers 413 Request Entity Too Large if err comes from a body over the server's size limit.
+func tooLarge(w http.ResponseWriter, err error) bool {
+	var maxErr *http.MaxBytesError
+	if !errors.As(err, &maxErr) {
+		return false
+	}
+	http.Error(w, fmt.Sprintf("Request body is larger than %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+	return true
+}
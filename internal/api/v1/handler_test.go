@@ -0,0 +1,442 @@
+package v1
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"TestTask/internal/service"
+	"TestTask/pkg/enrich"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeEnricher answers every name with the same attributes, or with err when set.
+type fakeEnricher struct {
+	err error
+}
+
+func (e *fakeEnricher) Enrich(_ context.Context, name string) (*enrich.Enriched, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &enrich.Enriched{
+		Age:                 40,
+		Gender:              "male",
+		Nationality:         "RU",
+		AgeProvider:         "fake",
+		GenderProvider:      "fake",
+		NationalityProvider: "fake",
+	}, nil
+}
+
+func (e *fakeEnricher) Refresh(ctx context.Context, name string) (*enrich.Enriched, error) {
+	return e.Enrich(ctx, name)
+}
+
+// pausedEnricher answers Refresh with other attributes than fakeEnricher, once the test lets it.
+type pausedEnricher struct {
+	fakeEnricher
+	started, resume chan struct{}
+}
+
+func (e *pausedEnricher) Refresh(ctx context.Context, name string) (*enrich.Enriched, error) {
+	close(e.started)
+	<-e.resume
+	return &enrich.Enriched{Age: 50, Gender: "female", Nationality: "UA"}, nil
+}
+
+// fakeQueue stores users as pending without enriching them.
+type fakeQueue struct {
+	users repository.UserRepository
+}
+
+func (q *fakeQueue) CreatePending(ctx context.Context, user *models.User) error {
+	user.EnrichmentStatus = models.EnrichmentPending
+	return q.users.Create(ctx, user)
+}
+
+// brokenDeletes fails every delete as a database outage would.
+type brokenDeletes struct {
+	*repository.MemoryUserRepository
+	err error
+}
+
+func (r brokenDeletes) Delete(context.Context, uint, uint) (bool, error) { return false, r.err }
+
+func (r brokenDeletes) Purge(context.Context, uint, uint) (bool, error) { return false, r.err }
+
+type testAPI struct {
+	t      *testing.T
+	router chi.Router
+}
+
+type testOptions struct {
+	enricher       service.Enricher
+	enrichErr      error
+	deleteErr      error
+	async          bool
+	requireIfMatch bool
+}
+
+// newTestAPI serves the v1 routes over an in-memory repository.
+func newTestAPI(t *testing.T, opts testOptions) *testAPI {
+	t.Helper()
+	var users repository.UserRepository = repository.NewMemoryUserRepository()
+	if opts.deleteErr != nil {
+		users = brokenDeletes{users.(*repository.MemoryUserRepository), opts.deleteErr}
+	}
+	var queue service.Queue
+	if opts.async {
+		queue = &fakeQueue{users: users}
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	enricher := opts.enricher
+	if enricher == nil {
+		enricher = &fakeEnricher{err: opts.enrichErr}
+	}
+	svc := service.NewUserService(users, enricher, queue, log)
+
+	router := chi.NewRouter()
+	router.Route("/api/v1", NewHandler(svc, log, opts.requireIfMatch).Routes)
+	return &testAPI{t: t, router: router}
+}
+
+// do sends a request with the given body and header name/value pairs.
+func (a *testAPI) do(method, target, body string, header ...string) *httptest.ResponseRecorder {
+	a.t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// create adds a user and returns its id.
+func (a *testAPI) create(name, surname string) uint {
+	a.t.Helper()
+	rec := a.do(http.MethodPost, "/api/v1/users", `{"Name":"`+name+`","Surname":"`+surname+`"}`)
+	if rec.Code != http.StatusCreated {
+		a.t.Fatalf("create: status = %d, body %s", rec.Code, rec.Body)
+	}
+	return decode[User](a.t, rec).ID
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.NewDecoder(rec.Body).Decode(&v); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body, err)
+	}
+	return v
+}
+
+func TestCreateUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       testOptions
+		body       string
+		wantStatus int
+		wantState  string
+	}{
+		{"enriched", testOptions{}, `{"Name":"Dmitriy","Surname":"Ushakov"}`, http.StatusCreated, models.EnrichmentSucceeded},
+		{"queued", testOptions{async: true}, `{"Name":"Dmitriy","Surname":"Ushakov"}`, http.StatusAccepted, models.EnrichmentPending},
+		{"missing surname", testOptions{}, `{"Name":"Dmitriy"}`, http.StatusBadRequest, ""},
+		{"malformed body", testOptions{}, `{"Name":`, http.StatusBadRequest, ""},
+		{"enrichment unavailable", testOptions{enrichErr: enrich.ErrCircuitOpen}, `{"Name":"Dmitriy","Surname":"Ushakov"}`, http.StatusServiceUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, tt.opts)
+			rec := api.do(http.MethodPost, "/api/v1/users", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantState == "" {
+				return
+			}
+			user := decode[User](t, rec)
+			if user.EnrichmentStatus != tt.wantState {
+				t.Errorf("EnrichmentStatus = %q, want %q", user.EnrichmentStatus, tt.wantState)
+			}
+			if got := rec.Header().Get("Location"); got != "/api/v1/users/1" {
+				t.Errorf("Location = %q", got)
+			}
+			if got := rec.Header().Get("ETag"); got != `"1"` {
+				t.Errorf("ETag = %q", got)
+			}
+			if tt.wantState == models.EnrichmentSucceeded && (user.Age != 40 || user.Nationality != "RU" || user.Enrichment == nil) {
+				t.Errorf("user not enriched: %+v", user)
+			}
+		})
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	api := newTestAPI(t, testOptions{})
+	api.create("Dmitriy", "Ushakov")
+
+	tests := []struct {
+		name       string
+		target     string
+		header     []string
+		wantStatus int
+	}{
+		{"found", "/api/v1/users/1", nil, http.StatusOK},
+		{"not modified", "/api/v1/users/1", []string{"If-None-Match", `W/"1"`}, http.StatusNotModified},
+		{"stale etag", "/api/v1/users/1", []string{"If-None-Match", `"7"`}, http.StatusOK},
+		{"unknown", "/api/v1/users/42", nil, http.StatusNotFound},
+		{"invalid id", "/api/v1/users/abc", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do(http.MethodGet, tt.target, "", tt.header...)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code == http.StatusOK && decode[User](t, rec).Name != "Dmitriy" {
+				t.Errorf("wrong user returned")
+			}
+		})
+	}
+}
+
+func TestGetUsers(t *testing.T) {
+	api := newTestAPI(t, testOptions{})
+	for _, name := range []string{"Anna", "Boris", "Vera"} {
+		api.create(name, "Ivanova")
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantNames  []string
+		wantNext   bool
+		wantTotal  string
+	}{
+		{"default page", "", http.StatusOK, []string{"Anna", "Boris", "Vera"}, false, ""},
+		{"first page", "?limit=2&count=true", http.StatusOK, []string{"Anna", "Boris"}, true, "3"},
+		{"second page", "?limit=2&page=2", http.StatusOK, []string{"Vera"}, false, ""},
+		{"sorted", "?sort=-name&limit=1", http.StatusOK, []string{"Vera"}, true, ""},
+		{"filtered", "?name=bo", http.StatusOK, []string{"Boris"}, false, ""},
+		{"invalid limit", "?limit=0", http.StatusBadRequest, nil, false, ""},
+		{"invalid sort", "?sort=password", http.StatusBadRequest, nil, false, ""},
+		{"invalid gender", "?gender=unknown", http.StatusBadRequest, nil, false, ""},
+		{"invalid cursor", "?cursor=garbage", http.StatusBadRequest, nil, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do(http.MethodGet, "/api/v1/users"+tt.query, "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			var names []string
+			for _, u := range decode[[]User](t, rec) {
+				names = append(names, u.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("users = %v, want %v", names, tt.wantNames)
+			}
+			if link := rec.Header().Get("Link"); (link != "") != tt.wantNext {
+				t.Errorf("Link = %q, want next page %v", link, tt.wantNext)
+			}
+			if got := rec.Header().Get("X-Total-Count"); got != tt.wantTotal {
+				t.Errorf("X-Total-Count = %q, want %q", got, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestGetUsersFollowsLink(t *testing.T) {
+	api := newTestAPI(t, testOptions{})
+	for _, name := range []string{"Anna", "Boris", "Vera"} {
+		api.create(name, "Ivanova")
+	}
+
+	var names []string
+	target := "/api/v1/users?limit=2&envelope=true"
+	for target != "" {
+		rec := api.do(http.MethodGet, target, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d, body %s", target, rec.Code, rec.Body)
+		}
+		page := decode[UserPage](t, rec)
+		for _, u := range page.Data {
+			names = append(names, u.Name)
+		}
+		target = strings.TrimSuffix(strings.TrimPrefix(rec.Header().Get("Link"), "<"), `>; rel="next"`)
+		if (target != "") != page.PageInfo.HasMore {
+			t.Fatalf("Link = %q but has_more = %v", target, page.PageInfo.HasMore)
+		}
+	}
+	if got := strings.Join(names, ","); got != "Anna,Boris,Vera" {
+		t.Errorf("users = %s", got)
+	}
+}
+
+func TestUpdateUserIfMatch(t *testing.T) {
+	const body = `{"Name":"Dmitriy","Surname":"Petrov","Age":41,"Gender":"male","Nationality":"RU"}`
+	tests := []struct {
+		name           string
+		requireIfMatch bool
+		header         []string
+		wantStatus     int
+	}{
+		{"unconditional", false, nil, http.StatusOK},
+		{"current version", false, []string{"If-Match", `"1"`}, http.StatusOK},
+		{"any version", false, []string{"If-Match", "*"}, http.StatusOK},
+		{"one of several", false, []string{"If-Match", `"5", "1"`}, http.StatusOK},
+		{"stale version", false, []string{"If-Match", `"2"`}, http.StatusPreconditionFailed},
+		{"weak tag", false, []string{"If-Match", `W/"1"`}, http.StatusPreconditionFailed},
+		{"required but missing", true, nil, http.StatusPreconditionRequired},
+		{"required and current", true, []string{"If-Match", `"1"`}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, testOptions{requireIfMatch: tt.requireIfMatch})
+			api.create("Dmitriy", "Ushakov")
+
+			rec := api.do(http.MethodPut, "/api/v1/users/1", body, tt.header...)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("ETag"); got != `"2"` {
+				t.Errorf("ETag = %q, want \"2\"", got)
+			}
+			if user := decode[User](t, rec); user.Surname != "Petrov" || user.Age != 41 {
+				t.Errorf("user not updated: %+v", user)
+			}
+		})
+	}
+}
+
+func TestPatchUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantSurname string
+	}{
+		{"merge patch", mergePatchType, `{"Surname":"Petrov"}`, http.StatusOK, "Petrov"},
+		{"json patch", jsonPatchType, `[{"op":"test","path":"/Surname","value":"Ushakov"},{"op":"replace","path":"/Surname","value":"Petrov"}]`, http.StatusOK, "Petrov"},
+		{"failed test", jsonPatchType, `[{"op":"test","path":"/Surname","value":"Petrov"}]`, http.StatusConflict, ""},
+		{"unknown field", mergePatchType, `{"Version":7}`, http.StatusBadRequest, ""},
+		{"gender copied from name", jsonPatchType, `[{"op":"copy","from":"/Name","path":"/Gender"}]`, http.StatusBadRequest, ""},
+		{"unsupported type", "text/plain", `Surname=Petrov`, http.StatusUnsupportedMediaType, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, testOptions{})
+			api.create("Dmitriy", "Ushakov")
+
+			rec := api.do(http.MethodPatch, "/api/v1/users/1", tt.body, "Content-Type", tt.contentType)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if user := decode[User](t, rec); user.Surname != tt.wantSurname || user.Name != "Dmitriy" {
+				t.Errorf("user = %+v, want surname %s", user, tt.wantSurname)
+			}
+		})
+	}
+}
+
+func TestDeleteAndRestoreUser(t *testing.T) {
+	api := newTestAPI(t, testOptions{})
+	api.create("Dmitriy", "Ushakov")
+
+	steps := []struct {
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{http.MethodPost, "/api/v1/users/1/restore", http.StatusConflict},
+		{http.MethodDelete, "/api/v1/users/1", http.StatusOK},
+		{http.MethodGet, "/api/v1/users/1", http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/users/1", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/users/1/restore", http.StatusOK},
+		{http.MethodGet, "/api/v1/users/1", http.StatusOK},
+		{http.MethodDelete, "/api/v1/users/1?purge=true", http.StatusOK},
+		{http.MethodPost, "/api/v1/users/1/restore", http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/users/42", http.StatusNotFound},
+	}
+	for _, s := range steps {
+		rec := api.do(s.method, s.target, "")
+		if rec.Code != s.wantStatus {
+			t.Fatalf("%s %s: status = %d, want %d, body %s", s.method, s.target, rec.Code, s.wantStatus, rec.Body)
+		}
+	}
+}
+
+func TestDeleteUserFailure(t *testing.T) {
+	api := newTestAPI(t, testOptions{deleteErr: errors.New("connection reset")})
+	api.create("Dmitriy", "Ushakov")
+
+	for _, target := range []string{"/api/v1/users/1", "/api/v1/users/1?purge=true"} {
+		if rec := api.do(http.MethodDelete, target, ""); rec.Code != http.StatusInternalServerError {
+			t.Errorf("DELETE %s: status = %d, want %d", target, rec.Code, http.StatusInternalServerError)
+		}
+	}
+}
+
+func TestReenrichUser(t *testing.T) {
+	api := newTestAPI(t, testOptions{})
+	api.create("Dmitriy", "Ushakov")
+
+	if rec := api.do(http.MethodPost, "/api/v1/users/1/enrich", ""); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := api.do(http.MethodPost, "/api/v1/users/42/enrich", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown user: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestPatchDuringReenrich(t *testing.T) {
+	enricher := &pausedEnricher{started: make(chan struct{}), resume: make(chan struct{})}
+	api := newTestAPI(t, testOptions{enricher: enricher})
+	api.create("Dmitriy", "Ushakov")
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- api.do(http.MethodPost, "/api/v1/users/1/enrich", "")
+	}()
+	<-enricher.started
+	patch := api.do(http.MethodPatch, "/api/v1/users/1", `{"Surname":"Petrov","Age":41}`, "Content-Type", mergePatchType)
+	if patch.Code != http.StatusOK {
+		t.Fatalf("PATCH: status = %d, body %s", patch.Code, patch.Body)
+	}
+	close(enricher.resume)
+	if rec := <-done; rec.Code != http.StatusOK {
+		t.Fatalf("enrich: status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	rec := api.do(http.MethodGet, "/api/v1/users/1", "")
+	user := decode[User](t, rec)
+	// The client's edits survive; the attributes it left alone take the fresh lookup.
+	want := User{Name: "Dmitriy", Surname: "Petrov", Age: 41, Gender: "female", Nationality: "UA", Version: 3}
+	if user.Name != want.Name || user.Surname != want.Surname || user.Age != want.Age ||
+		user.Gender != want.Gender || user.Nationality != want.Nationality || user.Version != want.Version {
+		t.Errorf("user = %+v, want %+v", user, want)
+	}
+	if got := rec.Header().Get("ETag"); got != `"3"` {
+		t.Errorf("ETag = %q, want \"3\"", got)
+	}
+}
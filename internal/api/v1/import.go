@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"TestTask/internal/importer"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// ImportUsers godoc
+// @Summary      Массовый импорт пользователей
+// @Description  Импортировать пользователей из CSV (колонки name, surname) или NDJSON, обогатить и сохранить пакетами
+// @Tags         users
+// @Accept       text/csv,application/x-ndjson
+// @Produce      json
+// @Param        format  query  string  false  "Формат (csv, ndjson); по умолчанию определяется по Content-Type"
+// @Success      200  {object}  importer.Report
+// @Failure      400  {string}  string "Bad request"
+// @Failure      413  {object}  importer.Report "Body over server.max_import_bytes; the rows before the limit are imported"
+// @Router       /api/v1/users/import [post]
+func (h *Handler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+
+	// A large import may take longer to upload and enrich than the server's read and write
+	// timeouts allow; the write deadline would otherwise cut off the report at the end.
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	report, err := h.users.ImportUsers(r.Context(), r.Body, format, importer.Options{})
+	if report == nil {
+		h.log.WarnContext(r.Context(), "Could not import users", "err", err)
+		if tooLarge(w, err) {
+			return
+		}
+		http.Error(w, "Could not import users: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	status := http.StatusOK
+	if err != nil {
+		// Rows read before the failure are already stored, so report them anyway.
+		h.log.ErrorContext(r.Context(), "Import stopped early", "err", err)
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+	}
+
+	h.log.InfoContext(r.Context(), "Users imported", "imported", report.Imported, "total", report.Total, "failed", report.Failed)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return importer.FormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/json-seq":
+		return importer.FormatNDJSON
+	}
+	return ""
+}
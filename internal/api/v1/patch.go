@@ -0,0 +1,292 @@
+package v1
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/service"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// patchFields maps the patchable v1 fields to their columns; it is the PATCH allowlist.
+var patchFields = map[string]string{
+	"Name":        "name",
+	"Surname":     "surname",
+	"Age":         "age",
+	"Gender":      "gender",
+	"Nationality": "nationality",
+}
+
+// JSONPatchOperation is one RFC 6902 operation. Paths address top-level fields, e.g. "/Age".
+type JSONPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// errPatchTest is returned when a JSON Patch "test" operation does not match.
+var errPatchTest = errors.New("test operation failed")
+
+// PatchUser godoc
+// @Summary      Частичное обновление пользователя
+// @Description  Изменить только переданные поля. application/merge-patch+json (RFC 7396, по умолчанию): отсутствующие поля не меняются, null сбрасывает поле.
+// @Description  application/json-patch+json (RFC 6902): операции add, remove, replace, move, copy, test над путями /Name, /Surname, /Age, /Gender, /Nationality.
+// @Tags         users
+// @Accept       application/merge-patch+json,application/json-patch+json
+// @Produce      json
+// @Param        id        path    int                   true   "user id"
+// @Param        If-Match  header  string                false  "ETag изменяемой версии"
+// @Param        patch     body    v1.UpdateUserRequest  true   "Merge patch с изменяемыми полями или массив операций JSON Patch"
+// @Success      200  {object}  v1.User
+// @Header       200  {string}  ETag  "Новая версия пользователя"
+// @Failure      400  {string}  string "Bad request"
+// @Failure      404  {string}  string "User not found"
+// @Failure      409  {string}  string "JSON Patch test failed"
+// @Failure      412  {string}  string "User was modified"
+// @Failure      415  {string}  string "Unsupported patch format"
+// @Failure      428  {string}  string "If-Match header is required"
+// @Router       /api/v1/users/{id} [patch]
+func (h *Handler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	id := userID(r)
+	if id <= 0 {
+		h.log.WarnContext(r.Context(), "Id field is empty")
+		http.Error(w, "Id field is empty", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.log.WarnContext(r.Context(), "Could not read request body", "err", err)
+		if tooLarge(w, err) {
+			return
+		}
+		http.Error(w, "Could not read request body!", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := h.ifMatch(w, r, id)
+	if !ok {
+		return
+	}
+	current, err := h.users.GetUser(r.Context(), id)
+	if err != nil {
+		h.log.WarnContext(r.Context(), "Could not find user", "user_id", id, "err", err)
+		http.Error(w, "Could not find user with id", http.StatusNotFound)
+		return
+	}
+	if version > 0 && version != current.Version {
+		h.preconditionFailed(w, r, id)
+		return
+	}
+	doc := map[string]interface{}{
+		"Name":        current.Name,
+		"Surname":     current.Surname,
+		"Age":         current.Age,
+		"Gender":      current.Gender,
+		"Nationality": current.Nationality,
+	}
+
+	var touched []string
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case mergePatchType, "application/json", "":
+		touched, err = applyMergePatch(doc, body)
+	case jsonPatchType:
+		touched, err = applyJSONPatch(doc, body)
+	default:
+		h.log.WarnContext(r.Context(), "Unsupported patch content type", "content_type", mediaType)
+		w.Header().Set("Accept-Patch", mergePatchType+", "+jsonPatchType)
+		http.Error(w, "Unsupported patch format", http.StatusUnsupportedMediaType)
+		return
+	}
+	if errors.Is(err, errPatchTest) {
+		h.log.WarnContext(r.Context(), "Patch rejected", "user_id", id, "err", err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.log.WarnContext(r.Context(), "Invalid patch", "user_id", id, "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changes := make(map[string]interface{}, len(touched))
+	for _, field := range touched {
+		changes[patchFields[field]] = doc[field]
+	}
+	// The patch was applied to the version just read, so the write is always conditional on it.
+	user, err := h.users.PatchUser(r.Context(), id, current.Version, changes)
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		h.log.WarnContext(r.Context(), "Invalid patch", "user_id", id, "err", err)
+		http.Error(w, validationErr.Message, http.StatusBadRequest)
+		return
+	case errors.Is(err, service.ErrVersionMismatch):
+		h.preconditionFailed(w, r, id)
+		return
+	case errors.Is(err, service.ErrNotFound):
+		h.log.WarnContext(r.Context(), "User not found", "user_id", id)
+		http.Error(w, "Could not find user with id", http.StatusNotFound)
+		return
+	case err != nil:
+		h.log.ErrorContext(r.Context(), "Could not patch user", "user_id", id, "err", err)
+		http.Error(w, "Could not update user", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "User patched", "user_id", id, "version", user.Version)
+	setETag(w, user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newUser(user))
+}
+
+// applyMergePatch applies an RFC 7396 merge patch to doc and returns the fields it changed.
+func applyMergePatch(doc map[string]interface{}, body []byte) ([]string, error) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return nil, fmt.Errorf("merge patch must be a JSON object: %w", err)
+	}
+
+	touched := make([]string, 0, len(patch))
+	for key, raw := range patch {
+		field, ok := patchField(key)
+		if !ok {
+			return nil, fmt.Errorf("field %s cannot be changed", key)
+		}
+		value, err := decodePatchValue(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		doc[field] = value
+		touched = append(touched, field)
+	}
+	return touched, nil
+}
+
+// applyJSONPatch applies RFC 6902 operations to doc in order and returns the fields they changed.
+// The operations are all-or-nothing: doc is only persisted if every operation succeeds.
+func applyJSONPatch(doc map[string]interface{}, body []byte) ([]string, error) {
+	var ops []JSONPatchOperation
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, fmt.Errorf("JSON Patch must be an array of operations: %w", err)
+	}
+
+	touchedSet := make(map[string]bool)
+	for i, op := range ops {
+		field, err := patchPath(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+
+		switch op.Op {
+		case "add", "replace":
+			if op.Value == nil {
+				return nil, fmt.Errorf("operation %d: value is required", i)
+			}
+			value, err := decodePatchValue(field, op.Value)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+			doc[field] = value
+			touchedSet[field] = true
+		case "remove":
+			doc[field] = zeroValue(field)
+			touchedSet[field] = true
+		case "copy", "move":
+			from, err := patchPath(op.From)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: from: %w", i, err)
+			}
+			if fmt.Sprintf("%T", doc[from]) != fmt.Sprintf("%T", doc[field]) {
+				return nil, fmt.Errorf("operation %d: cannot %s %s to %s", i, op.Op, op.From, op.Path)
+			}
+			doc[field] = doc[from]
+			touchedSet[field] = true
+			if op.Op == "move" && from != field {
+				doc[from] = zeroValue(from)
+				touchedSet[from] = true
+			}
+		case "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("operation %d: value is required", i)
+			}
+			value, err := decodePatchValue(field, op.Value)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+			if doc[field] != value {
+				return nil, fmt.Errorf("operation %d: %w: %s is %v", i, errPatchTest, op.Path, doc[field])
+			}
+		default:
+			return nil, fmt.Errorf("operation %d: unsupported op %q", i, op.Op)
+		}
+	}
+
+	touched := make([]string, 0, len(touchedSet))
+	for field := range touchedSet {
+		touched = append(touched, field)
+	}
+	return touched, nil
+}
+
+// patchField resolves key case-insensitively against the allowlist, like encoding/json does for struct fields.
+func patchField(key string) (string, bool) {
+	for field := range patchFields {
+		if strings.EqualFold(field, key) {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+func patchPath(path string) (string, error) {
+	if !strings.HasPrefix(path, "/") || strings.Count(path, "/") != 1 {
+		return "", fmt.Errorf("path %q must address a top-level field", path)
+	}
+	field, ok := patchField(path[1:])
+	if !ok {
+		return "", fmt.Errorf("field %s cannot be changed", path[1:])
+	}
+	return field, nil
+}
+
+// decodePatchValue decodes raw into the type of field; null yields the zero value.
+func decodePatchValue(field string, raw json.RawMessage) (interface{}, error) {
+	if raw == nil || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return zeroValue(field), nil
+	}
+	if field == "Age" {
+		var age int
+		if err := json.Unmarshal(raw, &age); err != nil {
+			return nil, fmt.Errorf("%s must be an integer", field)
+		}
+		return age, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("%s must be a string", field)
+	}
+	if field == "Gender" && !models.ValidGender(s) {
+		return nil, fmt.Errorf("%s must be %s or %s", field, models.GenderMale, models.GenderFemale)
+	}
+	return s, nil
+}
+
+func zeroValue(field string) interface{} {
+	if field == "Age" {
+		return 0
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package v1
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func patchDoc() map[string]interface{} {
+	return map[string]interface{}{
+		"Name":        "Dmitriy",
+		"Surname":     "Ushakov",
+		"Age":         40,
+		"Gender":      "male",
+		"Nationality": "RU",
+	}
+}
+
+type patchCase struct {
+	name        string
+	body        string
+	wantChanges map[string]interface{}
+	wantTouched []string
+	wantErr     bool
+	wantTestErr bool
+}
+
+func runPatchCases(t *testing.T, apply func(map[string]interface{}, []byte) ([]string, error), tests []patchCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := patchDoc()
+			touched, err := apply(doc, []byte(tt.body))
+			if tt.wantTestErr && !errors.Is(err, errPatchTest) {
+				t.Fatalf("err = %v, want errPatchTest", err)
+			}
+			if (err != nil) != (tt.wantErr || tt.wantTestErr) {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr || tt.wantTestErr)
+			}
+			if err != nil {
+				return
+			}
+
+			want := patchDoc()
+			for field, value := range tt.wantChanges {
+				want[field] = value
+			}
+			if !reflect.DeepEqual(doc, want) {
+				t.Errorf("doc = %v, want %v", doc, want)
+			}
+			sort.Strings(touched)
+			sort.Strings(tt.wantTouched)
+			if len(touched) != 0 || len(tt.wantTouched) != 0 {
+				if !reflect.DeepEqual(touched, tt.wantTouched) {
+					t.Errorf("touched = %v, want %v", touched, tt.wantTouched)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	runPatchCases(t, applyMergePatch, []patchCase{
+		{name: "empty", body: `{}`},
+		{name: "one field", body: `{"Age": 41}`,
+			wantChanges: map[string]interface{}{"Age": 41}, wantTouched: []string{"Age"}},
+		{name: "case-insensitive keys", body: `{"name": "Dima", "NATIONALITY": "UA"}`,
+			wantChanges: map[string]interface{}{"Name": "Dima", "Nationality": "UA"}, wantTouched: []string{"Name", "Nationality"}},
+		{name: "null resets", body: `{"Gender": null, "Age": null}`,
+			wantChanges: map[string]interface{}{"Gender": "", "Age": 0}, wantTouched: []string{"Age", "Gender"}},
+		{name: "unknown field", body: `{"ID": 5}`, wantErr: true},
+		{name: "version is not patchable", body: `{"Version": 5}`, wantErr: true},
+		{name: "gender", body: `{"Gender": "female"}`,
+			wantChanges: map[string]interface{}{"Gender": "female"}, wantTouched: []string{"Gender"}},
+		{name: "unknown gender", body: `{"Gender": "unknown"}`, wantErr: true},
+		{name: "capitalised gender", body: `{"Gender": "Female"}`, wantErr: true},
+		{name: "wrong type", body: `{"Age": "forty"}`, wantErr: true},
+		{name: "string for number field", body: `{"Name": 12}`, wantErr: true},
+		{name: "not an object", body: `[{"op": "remove", "path": "/Age"}]`, wantErr: true},
+		{name: "malformed", body: `{"Age":`, wantErr: true},
+	})
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	runPatchCases(t, applyJSONPatch, []patchCase{
+		{name: "empty", body: `[]`},
+		{name: "replace", body: `[{"op": "replace", "path": "/Age", "value": 41}]`,
+			wantChanges: map[string]interface{}{"Age": 41}, wantTouched: []string{"Age"}},
+		{name: "add", body: `[{"op": "add", "path": "/gender", "value": "female"}]`,
+			wantChanges: map[string]interface{}{"Gender": "female"}, wantTouched: []string{"Gender"}},
+		{name: "remove", body: `[{"op": "remove", "path": "/Nationality"}]`,
+			wantChanges: map[string]interface{}{"Nationality": ""}, wantTouched: []string{"Nationality"}},
+		{name: "copy", body: `[{"op": "copy", "from": "/Name", "path": "/Surname"}]`,
+			wantChanges: map[string]interface{}{"Surname": "Dmitriy"}, wantTouched: []string{"Surname"}},
+		{name: "move", body: `[{"op": "move", "from": "/Name", "path": "/Surname"}]`,
+			wantChanges: map[string]interface{}{"Name": "", "Surname": "Dmitriy"}, wantTouched: []string{"Name", "Surname"}},
+		{name: "move to itself", body: `[{"op": "move", "from": "/Name", "path": "/Name"}]`,
+			wantTouched: []string{"Name"}},
+		{name: "test then replace", body: `[{"op": "test", "path": "/Age", "value": 40}, {"op": "replace", "path": "/Age", "value": 41}]`,
+			wantChanges: map[string]interface{}{"Age": 41}, wantTouched: []string{"Age"}},
+		{name: "operations apply in order", body: `[{"op": "replace", "path": "/Age", "value": 41}, {"op": "test", "path": "/Age", "value": 41}]`,
+			wantChanges: map[string]interface{}{"Age": 41}, wantTouched: []string{"Age"}},
+		{name: "test fails", body: `[{"op": "test", "path": "/Age", "value": 39}, {"op": "replace", "path": "/Age", "value": 41}]`,
+			wantTestErr: true},
+		{name: "copy between types", body: `[{"op": "copy", "from": "/Name", "path": "/Age"}]`, wantErr: true},
+		{name: "missing value", body: `[{"op": "replace", "path": "/Age"}]`, wantErr: true},
+		{name: "test without value", body: `[{"op": "test", "path": "/Age"}]`, wantErr: true},
+		{name: "test without value of an empty field", body: `[{"op": "remove", "path": "/Nationality"}, {"op": "test", "path": "/Nationality"}]`, wantErr: true},
+		{name: "nested path", body: `[{"op": "replace", "path": "/Name/0", "value": "D"}]`, wantErr: true},
+		{name: "relative path", body: `[{"op": "replace", "path": "Name", "value": "D"}]`, wantErr: true},
+		{name: "unknown field", body: `[{"op": "replace", "path": "/Version", "value": 1}]`, wantErr: true},
+		{name: "unknown op", body: `[{"op": "increment", "path": "/Age"}]`, wantErr: true},
+		{name: "wrong type", body: `[{"op": "replace", "path": "/Age", "value": "old"}]`, wantErr: true},
+		{name: "unknown gender", body: `[{"op": "replace", "path": "/Gender", "value": "unknown"}]`, wantErr: true},
+		{name: "not an array", body: `{"Age": 41}`, wantErr: true},
+	})
+}
@@ -0,0 +1,22 @@
+package v1
+
+import (
+	"github.com/go-chi/chi"
+)
+
+// Routes registers the v1 user endpoints on r, which is mounted at /api/v1.
+func (h *Handler) Routes(r chi.Router) {
+	r.Route("/users", func(r chi.Router) {
+		r.Get("/", h.GetUsers)
+		r.Post("/", h.CreateUser)
+		r.Post("/import", h.ImportUsers)
+		r.Get("/export", h.ExportUsers)
+		r.Get("/stats", h.GetUserStats)
+		r.Get("/{id}", h.GetUser)
+		r.Put("/{id}", h.UpdateUser)
+		r.Patch("/{id}", h.PatchUser)
+		r.Delete("/{id}", h.DeleteUser)
+		r.Post("/{id}/enrich", h.ReenrichUser)
+		r.Post("/{id}/restore", h.RestoreUser)
+	})
+}
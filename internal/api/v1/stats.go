@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultBucketWidth = 10
+	maxBucketWidth     = 100
+)
+
+// GetUserStats godoc
+// @Summary      Статистика пользователей
+// @Description  Количество по полу, национальности и возрастным интервалам, средний и медианный возраст по национальностям и покрытие обогащением.
+// @Description  Учитывает те же фильтры, что и GET /api/v1/users. Пользователи с неизвестным возрастом (0) не входят в возрастные интервалы и средние.
+// @Tags         users
+// @Produce      json
+// @Param        bucket_width query  int     false  "Ширина возрастного интервала в годах, от 1 до 100 (по умолчанию 10)"
+// @Param        name        query   string  false  "Имя начинается с (без учёта регистра)"
+// @Param        surname     query   string  false  "Фамилия начинается с (без учёта регистра)"
+// @Param        q           query   string  false  "Нечёткий поиск по имени и фамилии (pg_trgm)"
+// @Param        age_min     query   int     false  "Мин. возраст"
+// @Param        age_max     query   int     false  "Макс. возраст"
+// @Param        gender      query   string  false  "Пол (male, female)"
+// @Param        nationality query   string  false  "Национальности через запятую (например RU,UA)"
+// @Param        created_after   query  string  false  "Созданы не раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        created_before  query  string  false  "Созданы раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        updated_after   query  string  false  "Изменены не раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        updated_before  query  string  false  "Изменены раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        gender_probability_min      query  number  false  "Мин. вероятность пола"
+// @Param        nationality_probability_min query  number  false  "Мин. вероятность национальности"
+// @Param        nationality_missing query  bool    false  "Только без национальности"
+// @Param        enrichment_status   query  string  false  "Статус обогащения (pending, succeeded, failed)"
+// @Param        enriched_before     query  string  false  "Обогащены раньше даты (RFC 3339 или YYYY-MM-DD)"
+// @Param        include_deleted     query  bool    false  "Включая удалённых"
+// @Success      200  {object}  v1.Stats
+// @Failure      400  {string}  string "Invalid filter"
+// @Failure      500  {string}  string "Internal Server Error"
+// @Router       /api/v1/users/stats [get]
+func (h *Handler) GetUserStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter, err := parseUserFilter(q)
+	if err != nil {
+		h.log.WarnContext(r.Context(), "Invalid filter", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	width := defaultBucketWidth
+	if raw := q.Get("bucket_width"); raw != "" {
+		width, err = strconv.Atoi(raw)
+		if err != nil || width < 1 || width > maxBucketWidth {
+			h.log.WarnContext(r.Context(), "Invalid bucket_width", "bucket_width", raw)
+			http.Error(w, fmt.Sprintf("bucket_width must be between 1 and %d", maxBucketWidth), http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := h.users.UserStats(r.Context(), filter, width)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Could not compute user stats", "err", err)
+		http.Error(w, "Could not compute user stats", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newStats(stats))
+}
@@ -0,0 +1,448 @@
+package v1
+
+import (
+	"TestTask/internal/api/query"
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"TestTask/internal/service"
+	"TestTask/pkg/enrich"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// GetUsers godoc
+// @Summary      Получение пользователей
+// @Description  Получить список пользователей с фильтрами, сортировкой и пагинацией. Некорректные параметры возвращают 400.
+// @Description  Ссылка на следующую страницу передаётся в заголовке Link (rel="next"). С envelope=true ответ — объект v1.UserPage с data и page_info.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        cursor      query   string  false  "Курсор следующей страницы из заголовка Link или page_info.next_cursor"
+// @Param        page        query   int     false  "Номер страницы (вместо cursor)"
+// @Param        limit       query   int     false  "Количество на странице, не больше 100 (по умолчанию 10)"
+// @Param        count       query   bool    false  "Вернуть общее количество в X-Total-Count (и page_info.total)"
+// @Param        envelope    query   bool    false  "Вернуть v1.UserPage вместо массива"
+// @Param        sort        query   string  false  "Сортировка через запятую, - для убывания: id, name, surname, age, gender, nationality, created_at, updated_at (например -age,surname)"
+// @Param        name        query   string  false  "Имя начинается с (без учёта регистра)"
+// @Param        surname     query   string  false  "Фамилия начинается с (без учёта регистра)"
+// @Param        q           query   string  false  "Нечёткий поиск по имени и фамилии (pg_trgm)"
+// @Param        age_min     query   int     false  "Мин. возраст"
+// @Param        age_max     query   int     false  "Макс. возраст"
+// @Param        gender      query   string  false  "Пол (male, female)"
+// @Param        nationality query   string  false  "Национальности через запятую (например RU,UA)"
+// @Param        created_after   query  string  false  "Созданы не раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        created_before  query  string  false  "Созданы раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        updated_after   query  string  false  "Изменены не раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        updated_before  query  string  false  "Изменены раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param        gender_probability_min      query  number  false  "Мин. вероятность пола"
+// @Param        nationality_probability_min query  number  false  "Мин. вероятность национальности"
+// @Param        nationality_missing query  bool    false  "Только без национальности"
+// @Param        enrichment_status   query  string  false  "Статус обогащения (pending, succeeded, failed)"
+// @Param        enriched_before     query  string  false  "Обогащены раньше даты (RFC 3339 или YYYY-MM-DD)"
+// @Param        include_deleted     query  bool    false  "Включая удалённых"
+// @Success      200  {array}   v1.User
+// @Header       200  {string}  Link           "Следующая страница, rel=next"
+// @Header       200  {int}     X-Total-Count  "Общее количество, если count=true"
+// @Failure      400  {string}  string "Invalid filter, sort or pagination"
+// @Router       /api/v1/users [get]
+func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	req, page, err := parsePage(q)
+	p := query.NewParser(q)
+	envelope := p.Bool("envelope")
+	if err == nil {
+		err = p.Err()
+	}
+	if err != nil {
+		h.log.WarnContext(r.Context(), "Invalid pagination", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filters, err := parseUserFilter(q)
+	if err != nil {
+		h.log.WarnContext(r.Context(), "Invalid filter", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, info, err := h.users.ListUsers(r.Context(), filters, req)
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		h.log.WarnContext(r.Context(), "Invalid pagination", "err", err)
+		http.Error(w, validationErr.Message, http.StatusBadRequest)
+		return
+	case err != nil:
+		h.log.ErrorContext(r.Context(), "Could not retrieve users", "err", err)
+		http.Error(w, "Failed to retrieve users", http.StatusInternalServerError)
+		return
+	}
+
+	if next := nextPage(r.URL, req, page, info); next != "" {
+		w.Header().Set("Link", "<"+next+`>; rel="next"`)
+	}
+	if info.Total != nil {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(*info.Total, 10))
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	// The bare array is the v1 contract; the envelope is opt-in.
+	if !envelope {
+		json.NewEncoder(w).Encode(newUsers(users))
+		return
+	}
+	res := UserPage{
+		Data: newUsers(users),
+		PageInfo: PageInfo{
+			Limit:      req.Limit,
+			NextCursor: info.NextCursor,
+			HasMore:    info.HasMore,
+			Total:      info.Total,
+		},
+	}
+	if req.Cursor == "" {
+		res.PageInfo.Page = page
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// nextPage returns the URL of the page after the one described by req and info, or "" on the last page.
+// It continues with a cursor when there is one, and with the next page number otherwise.
+func nextPage(u *url.URL, req repository.PageRequest, page int, info repository.PageInfo) string {
+	if !info.HasMore {
+		return ""
+	}
+	q := u.Query()
+	switch {
+	case info.NextCursor != "":
+		q.Del("page")
+		q.Set("cursor", info.NextCursor)
+	case req.Cursor == "":
+		q.Set("page", strconv.Itoa(page+1))
+	default:
+		return ""
+	}
+	next := url.URL{Path: u.Path, RawQuery: q.Encode()}
+	return next.String()
+}
+
+// CreateUser godoc
+// @Summary      Создание пользователя
+// @Description  Добавить нового пользователя и обогатить его данными
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        user  body  v1.CreateUserRequest  true  "User Data"
+// @Success      201  {object}  v1.User
+// @Success      202  {object}  v1.User "Accepted, enrichment pending"
+// @Failure      400  {string}  string "Bad request"
+// @Failure      500  {string}  string "Internal Server Error"
+// @Failure      503  {string}  string "Enrichment service unavailable"
+// @Router       /api/v1/users [post]
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var body CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.log.WarnContext(r.Context(), "Could not parse request body", "err", err)
+		if tooLarge(w, err) {
+			return
+		}
+		http.Error(w, "Could not parse request body!", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.users.CreateUser(r.Context(), body.Name, body.Surname)
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		h.log.WarnContext(r.Context(), "Invalid user", "err", err)
+		http.Error(w, validationErr.Message, http.StatusBadRequest)
+		return
+	case err != nil:
+		h.log.ErrorContext(r.Context(), "Could not create user", "err", err)
+		http.Error(w, "Could not create user", enrichmentErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/users/%d", user.ID))
+	setETag(w, user)
+	if user.EnrichmentStatus == models.EnrichmentPending {
+		h.log.InfoContext(r.Context(), "User created, enrichment pending", "user_id", user.ID)
+		w.WriteHeader(http.StatusAccepted)
+	} else {
+		h.log.InfoContext(r.Context(), "User created", "user_id", user.ID)
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(newUser(user))
+}
+
+// GetUser godoc
+// @Summary      Получение пользователя
+// @Description  Получить пользователя по ID вместе со статусом обогащения. Версия пользователя возвращается в заголовке ETag.
+// @Tags         users
+// @Produce      json
+// @Param        id             path    int     true   "ID пользователя"
+// @Param        If-None-Match  header  string  false  "ETag закэшированной версии"
+// @Success      200  {object}  v1.User
+// @Header       200  {string}  ETag  "Версия пользователя"
+// @Success      304  {string}  string "Not modified"
+// @Failure      400  {string}  string "Bad request"
+// @Failure      404  {string}  string "User not found"
+// @Router       /api/v1/users/{id} [get]
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id := userID(r)
+	if id <= 0 {
+		h.log.WarnContext(r.Context(), "Invalid user id")
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.users.GetUser(r.Context(), id)
+	if err != nil {
+		h.log.WarnContext(r.Context(), "Could not get user", "user_id", id, "err", err)
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	setETag(w, user)
+	if ifNoneMatch(r, user) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newUser(user))
+}
+
+// ReenrichUser godoc
+// @Summary      Повторное обогащение пользователя
+// @Description  Заново получить возраст, пол и национальность пользователя в обход кэша
+// @Tags         users
+// @Produce      json
+// @Param        id  path  int  true  "ID пользователя"
+// @Success      200  {object}  v1.User
+// @Failure      400  {string}  string "Bad request"
+// @Failure      404  {string}  string "User not found"
+// @Failure      500  {string}  string "Internal Server Error"
+// @Failure      503  {string}  string "Enrichment service unavailable"
+// @Router       /api/v1/users/{id}/enrich [post]
+func (h *Handler) ReenrichUser(w http.ResponseWriter, r *http.Request) {
+	id := userID(r)
+	if id <= 0 {
+		h.log.WarnContext(r.Context(), "Invalid user id")
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.users.ReenrichUser(r.Context(), id)
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		h.log.WarnContext(r.Context(), "User not found", "user_id", id)
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	case err != nil:
+		h.log.ErrorContext(r.Context(), "Re-enrichment failed", "user_id", id, "err", err)
+		http.Error(w, "Enrichment failed", enrichmentErrorStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "User re-enriched", "user_id", id)
+	setETag(w, user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newUser(user))
+}
+
+// DeleteUser godoc
+// @Summary      Удаление пользователя
+// @Description  Удалить пользователя по ID. Удалённого пользователя можно восстановить, пока он не стёрт окончательно.
+// @Description  С purge=true пользователь и данные обогащения стираются сразу и безвозвратно.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id        path    int     true   "ID пользователя"
+// @Param        purge     query   bool    false  "Стереть безвозвратно"
+// @Param        If-Match  header  string  false  "ETag удаляемой версии"
+// @Success      200  {object}  v1.MessageResponse "User deleted"
+// @Failure      400  {string}  string "Bad request"
+// @Failure      404  {string}  string "User not found"
+// @Failure      412  {string}  string "User was modified"
+// @Failure      428  {string}  string "If-Match header is required"
+// @Failure      500  {string}  string "Internal Server Error"
+// @Router       /api/v1/users/{id} [delete]
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id := userID(r)
+	if id <= 0 {
+		h.log.WarnContext(r.Context(), "Id field is empty")
+		http.Error(w, "Missing id parameter", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := h.ifMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	purge, _ := strconv.ParseBool(r.URL.Query().Get("purge"))
+	message := "User deleted"
+	var err error
+	if purge {
+		message = "User purged"
+		err = h.users.PurgeUser(r.Context(), id, version)
+	} else {
+		err = h.users.DeleteUser(r.Context(), id, version)
+	}
+	switch {
+	case errors.Is(err, service.ErrVersionMismatch):
+		h.preconditionFailed(w, r, id)
+		return
+	case errors.Is(err, service.ErrNotFound):
+		h.log.WarnContext(r.Context(), "User not found", "user_id", id)
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	case err != nil:
+		h.log.ErrorContext(r.Context(), "Could not delete user", "user_id", id, "err", err)
+		http.Error(w, "Could not delete user", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.InfoContext(r.Context(), message, "user_id", id)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(MessageResponse{Message: message})
+}
+
+// RestoreUser godoc
+// @Summary      Восстановление пользователя
+// @Description  Восстановить удалённого пользователя, если он ещё не стёрт
+// @Tags         users
+// @Produce      json
+// @Param        id  path  int  true  "ID пользователя"
+// @Success      200  {object}  v1.User
+// @Failure      400  {string}  string "Bad request"
+// @Failure      404  {string}  string "User not found"
+// @Failure      409  {string}  string "User is not deleted"
+// @Router       /api/v1/users/{id}/restore [post]
+func (h *Handler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	id := userID(r)
+	if id <= 0 {
+		h.log.WarnContext(r.Context(), "Invalid user id")
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.users.RestoreUser(r.Context(), id)
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		h.log.WarnContext(r.Context(), "User not found", "user_id", id)
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrNotDeleted):
+		h.log.WarnContext(r.Context(), "User is not deleted", "user_id", id)
+		http.Error(w, "User is not deleted", http.StatusConflict)
+		return
+	case err != nil:
+		h.log.ErrorContext(r.Context(), "Could not restore user", "user_id", id, "err", err)
+		http.Error(w, "Could not restore user", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "User restored", "user_id", id)
+	setETag(w, user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newUser(user))
+}
+
+// UpdateUser godoc
+// @Summary      Обновление пользователя
+// @Description  Обновить пользователя по ID
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id        path    int                   true   "user id"
+// @Param        If-Match  header  string                false  "ETag изменяемой версии"
+// @Param        user      body    v1.UpdateUserRequest  true   "updated data"
+// @Success      200  {object}  v1.User
+// @Header       200  {string}  ETag  "Новая версия пользователя"
+// @Failure      400  {string}  string "Bad request"
+// @Failure      412  {string}  string "User was modified"
+// @Failure      428  {string}  string "If-Match header is required"
+// @Failure      500  {string}  string "Internal Server Error"
+// @Router       /api/v1/users/{id} [put]
+func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id := userID(r)
+	if id <= 0 {
+		h.log.WarnContext(r.Context(), "Id field is empty")
+		http.Error(w, "Id field is empty", http.StatusBadRequest)
+		return
+	}
+
+	var body UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.log.WarnContext(r.Context(), "Could not parse request body", "err", err)
+		if tooLarge(w, err) {
+			return
+		}
+		http.Error(w, "Could not parse request body!", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := h.ifMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	user, err := h.users.UpdateUser(r.Context(), id, version, service.UserFields{
+		Name:        body.Name,
+		Surname:     body.Surname,
+		Age:         body.Age,
+		Gender:      body.Gender,
+		Nationality: body.Nationality,
+	})
+	var validationErr *service.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		h.log.WarnContext(r.Context(), "Invalid user", "err", err)
+		http.Error(w, validationErr.Message, http.StatusBadRequest)
+		return
+	case errors.Is(err, service.ErrVersionMismatch):
+		h.preconditionFailed(w, r, id)
+		return
+	case errors.Is(err, service.ErrNotFound):
+		h.log.WarnContext(r.Context(), "User not found", "user_id", id)
+		http.Error(w, "Could not find user with id", http.StatusNotFound)
+		return
+	case err != nil:
+		h.log.ErrorContext(r.Context(), "Could not update user", "user_id", id, "err", err)
+		http.Error(w, "Could not update user", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "User updated", "user_id", id, "version", user.Version)
+	setETag(w, user)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newUser(user))
+}
+
+// enrichmentErrorStatus maps upstream outages to 503 so clients know the request can be retried later.
+func enrichmentErrorStatus(err error) int {
+	var statusErr *enrich.StatusError
+	if errors.Is(err, enrich.ErrCircuitOpen) || errors.As(err, &statusErr) || errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// userID reads the user id from the {id} URL parameter, falling back to the ?id= query
+// parameter used by the deprecated routes.
+func userID(r *http.Request) int {
+	raw := chi.URLParam(r, "id")
+	if raw == "" {
+		raw = r.URL.Query().Get("id")
+	}
+	id, _ := strconv.Atoi(raw)
+	return id
+}
@@ -1,37 +1,403 @@
+// Package config loads the settings shared by every command.
+//
+// Values are resolved with increasing precedence from the built-in defaults, the YAML file,
+// environment variables (also read from an optional .env file) and command-line flags.
+// Every leaf setting can be overridden by an environment variable named APP_ followed by
+// its upper-cased YAML path, e.g. APP_SERVER_ADDR or APP_CACHE_TTL.
 package config
 
 import (
-	"TestTask/pkg/logger"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// DefaultPath is the configuration file read when none is given.
+const DefaultPath = "config.yaml"
+
+// envPrefix starts the name of every environment override.
+const envPrefix = "APP_"
+
 type Config struct {
+	Server struct {
+		Addr string `yaml:"addr"`
+		// Timeouts of the http.Server; 0 means none. The export is exempt from the write timeout,
+		// the import from both the read and the write timeouts.
+		ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+		ReadTimeout       time.Duration `yaml:"read_timeout"`
+		WriteTimeout      time.Duration `yaml:"write_timeout"`
+		IdleTimeout       time.Duration `yaml:"idle_timeout"`
+		// Size limits in bytes; MaxImportBytes replaces MaxBodyBytes for the bulk import.
+		MaxHeaderBytes int `yaml:"max_header_bytes"`
+		MaxBodyBytes   int `yaml:"max_body_bytes"`
+		MaxImportBytes int `yaml:"max_import_bytes"`
+		// ShutdownTimeout is how long requests and enrichment jobs in flight may take to finish
+		// after SIGINT or SIGTERM before they are interrupted.
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	} `yaml:"server"`
+	Database struct {
+		// URL is the Postgres DSN. The DBurl variable of older .env files is still honored.
+		URL string `yaml:"url"`
+		// AutoMigrate applies pending migrations when the server starts.
+		AutoMigrate bool `yaml:"auto_migrate"`
+		// Pool limits of the underlying sql.DB; 0 means no limit.
+		MaxOpenConns    int           `yaml:"max_open_conns"`
+		MaxIdleConns    int           `yaml:"max_idle_conns"`
+		ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+		ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+		// ConnectAttempts is how many times the database is tried at startup, waiting
+		// ConnectBaseDelay after the first failure and twice as long after each next one, up to ConnectMaxDelay.
+		ConnectAttempts  int           `yaml:"connect_attempts"`
+		ConnectBaseDelay time.Duration `yaml:"connect_base_delay"`
+		ConnectMaxDelay  time.Duration `yaml:"connect_max_delay"`
+	} `yaml:"database"`
+	Log struct {
+		// Level is debug, info, warn or error; Format is text or json.
+		Level  string `yaml:"level"`
+		Format string `yaml:"format"`
+	} `yaml:"log"`
+	API struct {
+		RequireIfMatch bool `yaml:"require_if_match"`
+	} `yaml:"api"`
+	Admin struct {
+		// Token is required as a bearer token by the /admin endpoints; without it they only answer localhost.
+		Token string `yaml:"token"`
+	} `yaml:"admin"`
 	URL struct {
 		Age         string `yaml:"age"`
 		Gender      string `yaml:"gender"`
 		Nationality string `yaml:"nationality"`
 	} `yaml:"url"`
+	Providers struct {
+		Age         string `yaml:"age"`
+		Gender      string `yaml:"gender"`
+		Nationality string `yaml:"nationality"`
+	} `yaml:"providers"`
+	Timeouts struct {
+		Overall     time.Duration `yaml:"overall"`
+		Age         time.Duration `yaml:"age"`
+		Gender      time.Duration `yaml:"gender"`
+		Nationality time.Duration `yaml:"nationality"`
+	} `yaml:"timeouts"`
+	Cache struct {
+		Size     int           `yaml:"size"`
+		TTL      time.Duration `yaml:"ttl"`
+		Postgres bool          `yaml:"postgres"`
+	} `yaml:"cache"`
+	Retry struct {
+		Attempts  int           `yaml:"attempts"`
+		BaseDelay time.Duration `yaml:"base_delay"`
+		MaxDelay  time.Duration `yaml:"max_delay"`
+	} `yaml:"retry"`
+	Breaker struct {
+		Failures int           `yaml:"failures"`
+		Cooldown time.Duration `yaml:"cooldown"`
+	} `yaml:"breaker"`
+	Retention struct {
+		// PurgeAfter is how long soft-deleted users are kept before the scheduled purge erases them;
+		// 0, the default, keeps them.
+		PurgeAfter    time.Duration `yaml:"purge_after"`
+		PurgeInterval time.Duration `yaml:"purge_interval"`
+	} `yaml:"retention"`
+	Async struct {
+		Enabled      bool          `yaml:"enabled"`
+		Workers      int           `yaml:"workers"`
+		Attempts     int           `yaml:"attempts"`
+		Backoff      time.Duration `yaml:"backoff"`
+		MaxBackoff   time.Duration `yaml:"max_backoff"`
+		PollInterval time.Duration `yaml:"poll_interval"`
+		LockTimeout  time.Duration `yaml:"lock_timeout"`
+	} `yaml:"async"`
+	Reload struct {
+		// WatchInterval is how often the file is checked for changes; 0 reloads on SIGHUP only.
+		WatchInterval time.Duration `yaml:"watch_interval"`
+	} `yaml:"reload"`
 }
 
-func LoadEnv() {
-	err := godotenv.Load()
-	if err != nil {
-		logger.Logger.Fatal("Could not load env file!", err)
+// Default returns the settings used for everything the file, environment and flags leave out.
+func Default() *Config {
+	var cfg Config
+	cfg.Server.Addr = ":8080"
+	cfg.Server.ReadHeaderTimeout = 5 * time.Second
+	cfg.Server.ReadTimeout = 30 * time.Second
+	cfg.Server.WriteTimeout = time.Minute
+	cfg.Server.IdleTimeout = 2 * time.Minute
+	cfg.Server.MaxHeaderBytes = 1 << 20
+	cfg.Server.MaxBodyBytes = 1 << 20
+	cfg.Server.MaxImportBytes = 64 << 20
+	cfg.Server.ShutdownTimeout = 30 * time.Second
+	cfg.Database.AutoMigrate = true
+	cfg.Database.MaxOpenConns = 20
+	cfg.Database.MaxIdleConns = 5
+	cfg.Database.ConnMaxLifetime = 30 * time.Minute
+	cfg.Database.ConnMaxIdleTime = 5 * time.Minute
+	cfg.Database.ConnectAttempts = 10
+	cfg.Database.ConnectBaseDelay = 500 * time.Millisecond
+	cfg.Database.ConnectMaxDelay = 10 * time.Second
+	cfg.Log.Level = "info"
+	cfg.Log.Format = "text"
+	cfg.URL.Age = "https://api.agify.io/?name=%s"
+	cfg.URL.Gender = "https://api.genderize.io/?name=%s"
+	cfg.URL.Nationality = "https://api.nationalize.io/?name=%s"
+	cfg.Providers.Age = "agify"
+	cfg.Providers.Gender = "genderize"
+	cfg.Providers.Nationality = "nationalize"
+	cfg.Timeouts.Overall = 10 * time.Second
+	cfg.Timeouts.Age = 5 * time.Second
+	cfg.Timeouts.Gender = 5 * time.Second
+	cfg.Timeouts.Nationality = 5 * time.Second
+	cfg.Cache.Size = 10000
+	cfg.Cache.TTL = 720 * time.Hour
+	cfg.Retry.Attempts = 3
+	cfg.Retry.BaseDelay = 200 * time.Millisecond
+	cfg.Retry.MaxDelay = 2 * time.Second
+	cfg.Breaker.Failures = 5
+	cfg.Breaker.Cooldown = 30 * time.Second
+	cfg.Retention.PurgeInterval = time.Hour
+	cfg.Async.Workers = 4
+	cfg.Async.Attempts = 5
+	cfg.Async.Backoff = 5 * time.Second
+	cfg.Async.MaxBackoff = time.Hour
+	cfg.Async.PollInterval = time.Second
+	cfg.Async.LockTimeout = 5 * time.Minute
+	cfg.Reload.WatchInterval = 5 * time.Second
+	return &cfg
+}
+
+// Flags are the command-line overrides understood by every command.
+type Flags struct {
+	fs *flag.FlagSet
+
+	path      string
+	addr      string
+	dbURL     string
+	logLevel  string
+	logFormat string
+}
+
+// BindFlags registers the configuration flags on fs. Call Load after fs is parsed.
+func BindFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs}
+	fs.StringVar(&f.path, "config", "", "configuration file (default $APP_CONFIG or "+DefaultPath+")")
+	fs.StringVar(&f.addr, "addr", "", "HTTP listen address, overrides server.addr")
+	fs.StringVar(&f.dbURL, "db-url", "", "Postgres DSN, overrides database.url")
+	fs.StringVar(&f.logLevel, "log-level", "", "debug, info, warn or error, overrides log.level")
+	fs.StringVar(&f.logFormat, "log-format", "", "text or json, overrides log.format")
+	return f
+}
+
+// Load resolves and validates the configuration. A missing .env file, or a missing
+// configuration file at the default path, is not an error.
+func (f *Flags) Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf(".env: %w", err)
+	}
+
+	path, explicit := f.configPath()
+	cfg := Default()
+	if err := readFile(path, cfg); err != nil {
+		if explicit || !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
 	}
+	if url := os.Getenv("DBurl"); url != "" {
+		cfg.Database.URL = url
+	}
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), envPrefix); err != nil {
+		return nil, err
+	}
+
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "addr":
+			cfg.Server.Addr = f.addr
+		case "db-url":
+			cfg.Database.URL = f.dbURL
+		case "log-level":
+			cfg.Log.Level = f.logLevel
+		case "log-format":
+			cfg.Log.Format = f.logFormat
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Path returns the configuration file Load reads.
+func (f *Flags) Path() string {
+	path, _ := f.configPath()
+	return path
 }
 
-func LoadYaml(path string) *Config {
-	data, err := os.ReadFile(path)
+// configPath also reports whether the file was named explicitly, in which case it must exist.
+func (f *Flags) configPath() (path string, explicit bool) {
+	path, explicit = f.path, f.path != ""
+	if !explicit {
+		path, explicit = os.LookupEnv(envPrefix + "CONFIG")
+	}
+	if path == "" {
+		path = DefaultPath
+	}
+	return path, explicit
+}
+
+// readFile merges the YAML file at path into cfg. Unknown keys are rejected so typos do not go unnoticed.
+func readFile(path string, cfg *Config) error {
+	file, err := os.Open(path)
 	if err != nil {
-		logger.Logger.Fatal("Could not load config file!", err)
+		return err
+	}
+	defer file.Close()
+
+	dec := yaml.NewDecoder(file)
+	dec.KnownFields(true)
+	err = dec.Decode(cfg)
+	var typeErr *yaml.TypeError
+	switch {
+	case err == nil, errors.Is(err, io.EOF):
 		return nil
+	case errors.As(err, &typeErr):
+		// Drop the Go type names of the anonymous section structs from the messages.
+		for i, msg := range typeErr.Errors {
+			if at := strings.Index(msg, " in type struct"); at >= 0 {
+				typeErr.Errors[i] = msg[:at]
+			}
+		}
 	}
-	var cfg Config
-	if err = yaml.Unmarshal(data, &cfg); err != nil {
-		logger.Logger.Fatal("Could not load config file!", err)
+	return fmt.Errorf("%s: %w", path, err)
+}
+
+// applyEnv overrides the fields of the struct v from the environment variables named
+// prefix followed by the upper-cased YAML path of each field.
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.ToUpper(strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0])
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, prefix+name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := prefix + name
+		raw, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		if err := setField(field, raw); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
 		return nil
 	}
-	return &cfg
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, setting, problem string) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", setting, problem))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr", "is required")
+	check(c.Database.URL != "", "database.url", "is required, set it in the file, APP_DATABASE_URL or -db-url")
+	check(oneOf(c.Log.Level, "debug", "info", "warn", "error"), "log.level", "must be debug, info, warn or error")
+	check(oneOf(c.Log.Format, "text", "json"), "log.format", "must be text or json")
+
+	for _, url := range []struct{ setting, value string }{
+		{"url.age", c.URL.Age}, {"url.gender", c.URL.Gender}, {"url.nationality", c.URL.Nationality},
+	} {
+		check(url.value == "" || strings.Count(url.value, "%s") == 1, url.setting, "must contain one %s for the name")
+	}
+	for _, d := range []struct {
+		setting string
+		value   time.Duration
+	}{
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout}, {"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout}, {"server.idle_timeout", c.Server.IdleTimeout},
+		{"database.conn_max_lifetime", c.Database.ConnMaxLifetime}, {"database.conn_max_idle_time", c.Database.ConnMaxIdleTime},
+		{"database.connect_base_delay", c.Database.ConnectBaseDelay}, {"database.connect_max_delay", c.Database.ConnectMaxDelay},
+		{"timeouts.overall", c.Timeouts.Overall}, {"timeouts.age", c.Timeouts.Age},
+		{"timeouts.gender", c.Timeouts.Gender}, {"timeouts.nationality", c.Timeouts.Nationality},
+		{"cache.ttl", c.Cache.TTL}, {"retry.base_delay", c.Retry.BaseDelay}, {"retry.max_delay", c.Retry.MaxDelay},
+		{"breaker.cooldown", c.Breaker.Cooldown}, {"retention.purge_after", c.Retention.PurgeAfter},
+		{"async.backoff", c.Async.Backoff}, {"async.max_backoff", c.Async.MaxBackoff},
+		{"async.lock_timeout", c.Async.LockTimeout},
+		{"reload.watch_interval", c.Reload.WatchInterval},
+	} {
+		check(d.value >= 0, d.setting, "must not be negative")
+	}
+	check(c.Server.MaxHeaderBytes > 0, "server.max_header_bytes", "must be positive")
+	check(c.Server.MaxBodyBytes > 0, "server.max_body_bytes", "must be positive")
+	check(c.Server.MaxImportBytes > 0, "server.max_import_bytes", "must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns", "must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns", "must not be negative")
+	check(c.Database.ConnectAttempts > 0, "database.connect_attempts", "must be positive")
+	check(c.Cache.Size >= 0, "cache.size", "must not be negative")
+	check(c.Retry.Attempts >= 0, "retry.attempts", "must not be negative")
+	check(c.Breaker.Failures >= 0, "breaker.failures", "must not be negative")
+	check(c.Retention.PurgeAfter == 0 || c.Retention.PurgeInterval > 0, "retention.purge_interval", "must be positive when purge_after is set")
+	if c.Async.Enabled {
+		check(c.Async.Workers > 0, "async.workers", "must be positive")
+		check(c.Async.Attempts > 0, "async.attempts", "must be positive")
+		check(c.Async.MaxBackoff > 0, "async.max_backoff", "must be positive")
+		check(c.Async.PollInterval > 0, "async.poll_interval", "must be positive")
+	}
+	return errors.Join(errs...)
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return true
+		}
+	}
+	return false
 }
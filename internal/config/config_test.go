@@ -0,0 +1,267 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a YAML configuration file to a temporary directory and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	const file = `
+server:
+  addr: ":9000"
+database:
+  url: postgres://file
+log:
+  level: warn
+cache:
+  ttl: 1h
+`
+	tests := []struct {
+		name  string
+		env   map[string]string
+		args  []string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "file over defaults",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Addr != ":9000" || cfg.Log.Level != "warn" || cfg.Cache.TTL != time.Hour {
+					t.Errorf("file not applied: addr %q, level %q, ttl %v", cfg.Server.Addr, cfg.Log.Level, cfg.Cache.TTL)
+				}
+				if cfg.Log.Format != "text" || cfg.Cache.Size != 10000 {
+					t.Errorf("defaults lost: format %q, size %d", cfg.Log.Format, cfg.Cache.Size)
+				}
+				if cfg.Retention.PurgeAfter != 0 {
+					t.Errorf("scheduled purge enabled by default: purge_after %v", cfg.Retention.PurgeAfter)
+				}
+			},
+		},
+		{
+			name: "env over file",
+			env:  map[string]string{"APP_SERVER_ADDR": ":9100", "APP_CACHE_TTL": "2h", "APP_ASYNC_ENABLED": "true"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Addr != ":9100" || cfg.Cache.TTL != 2*time.Hour || !cfg.Async.Enabled {
+					t.Errorf("env not applied: addr %q, ttl %v, async %v", cfg.Server.Addr, cfg.Cache.TTL, cfg.Async.Enabled)
+				}
+			},
+		},
+		{
+			name: "legacy DBurl",
+			env:  map[string]string{"DBurl": "postgres://legacy"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Database.URL != "postgres://legacy" {
+					t.Errorf("database.url = %q", cfg.Database.URL)
+				}
+			},
+		},
+		{
+			name: "APP_DATABASE_URL over DBurl",
+			env:  map[string]string{"DBurl": "postgres://legacy", "APP_DATABASE_URL": "postgres://env"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Database.URL != "postgres://env" {
+					t.Errorf("database.url = %q", cfg.Database.URL)
+				}
+			},
+		},
+		{
+			name: "flags over env",
+			env:  map[string]string{"APP_SERVER_ADDR": ":9100", "APP_DATABASE_URL": "postgres://env", "APP_LOG_FORMAT": "json"},
+			args: []string{"-addr", ":9200", "-db-url", "postgres://flag", "-log-level", "debug"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Addr != ":9200" || cfg.Database.URL != "postgres://flag" || cfg.Log.Level != "debug" {
+					t.Errorf("flags not applied: addr %q, url %q, level %q", cfg.Server.Addr, cfg.Database.URL, cfg.Log.Level)
+				}
+				if cfg.Log.Format != "json" {
+					t.Errorf("unset flag overrode env: format %q", cfg.Log.Format)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DBurl", "")
+			t.Setenv("APP_CONFIG", writeConfig(t, file))
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags := BindFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := flags.Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadConfigPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    bool
+		env     bool
+		path    string
+		wantErr string
+	}{
+		{"missing default file", false, false, "", ""},
+		{"missing file from flag", true, false, "missing.yaml", "missing.yaml"},
+		{"missing file from env", false, true, "missing.yaml", "missing.yaml"},
+		{"unknown key", true, false, "server:\n  adr: \":9000\"\n", "field adr not found"},
+		{"wrong type", true, false, "cache:\n  size: many\n", "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			t.Setenv("DBurl", "")
+			t.Setenv("APP_DATABASE_URL", "postgres://env")
+			// t.Setenv restores APP_CONFIG afterwards; the default path is only used when it is unset.
+			t.Setenv("APP_CONFIG", "")
+			os.Unsetenv("APP_CONFIG")
+
+			path := tt.path
+			if strings.Contains(path, "\n") {
+				path = writeConfig(t, path)
+			}
+			var args []string
+			if tt.flag {
+				args = []string{"-config", path}
+			}
+			if tt.env {
+				t.Setenv("APP_CONFIG", path)
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags := BindFlags(fs)
+			if err := fs.Parse(args); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := flags.Load()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Load: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("Load error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	type settings struct {
+		Name    string        `yaml:"name"`
+		Enabled bool          `yaml:"enabled"`
+		Count   int           `yaml:"count"`
+		Delay   time.Duration `yaml:"delay"`
+		Nested  struct {
+			Level string `yaml:"level,omitempty"`
+		} `yaml:"nested"`
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    func(s *settings)
+		wantErr string
+	}{
+		{"nothing set", nil, func(s *settings) {}, ""},
+		{"string", map[string]string{"T_NAME": "x"}, func(s *settings) { s.Name = "x" }, ""},
+		{"empty string", map[string]string{"T_NAME": ""}, func(s *settings) { s.Name = "" }, ""},
+		{"bool", map[string]string{"T_ENABLED": "true"}, func(s *settings) { s.Enabled = true }, ""},
+		{"int", map[string]string{"T_COUNT": "7"}, func(s *settings) { s.Count = 7 }, ""},
+		{"duration", map[string]string{"T_DELAY": "1m30s"}, func(s *settings) { s.Delay = 90 * time.Second }, ""},
+		{"nested", map[string]string{"T_NESTED_LEVEL": "debug"}, func(s *settings) { s.Nested.Level = "debug" }, ""},
+		{"invalid bool", map[string]string{"T_ENABLED": "sometimes"}, nil, "T_ENABLED"},
+		{"invalid int", map[string]string{"T_COUNT": "7.5"}, nil, "T_COUNT"},
+		{"duration without unit", map[string]string{"T_DELAY": "5"}, nil, "T_DELAY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			got := settings{Name: "default", Count: 1}
+			err := applyEnv(reflect.ValueOf(&got).Elem(), "T_")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to name %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := settings{Name: "default", Count: 1}
+			tt.want(&want)
+			if got != want {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{"defaults with a database", func(c *Config) {}, nil},
+		{"missing database", func(c *Config) { c.Database.URL = "" }, []string{"database.url"}},
+		{"log settings", func(c *Config) { c.Log.Level, c.Log.Format = "loud", "xml" }, []string{"log.level", "log.format"}},
+		{"upper-case level", func(c *Config) { c.Log.Level = "DEBUG" }, nil},
+		{"provider url", func(c *Config) { c.URL.Age = "https://api.agify.io/" }, []string{"url.age"}},
+		{"negative duration", func(c *Config) { c.Cache.TTL = -time.Second }, []string{"cache.ttl"}},
+		{"zero limits", func(c *Config) { c.Server.MaxBodyBytes, c.Server.ShutdownTimeout = 0, 0 }, []string{"server.max_body_bytes", "server.shutdown_timeout"}},
+		{"purge without interval", func(c *Config) { c.Retention.PurgeAfter, c.Retention.PurgeInterval = 720*time.Hour, 0 }, []string{"retention.purge_interval"}},
+		{"purge disabled", func(c *Config) { c.Retention.PurgeInterval = 0 }, nil},
+		{"async workers ignored when disabled", func(c *Config) { c.Async.Workers = 0 }, nil},
+		{"async workers", func(c *Config) { c.Async.Enabled, c.Async.Workers = true, 0 }, []string{"async.workers"}},
+		{"async max backoff", func(c *Config) { c.Async.Enabled, c.Async.MaxBackoff = true, 0 }, []string{"async.max_backoff"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Database.URL = "postgres://test"
+			tt.change(cfg)
+
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate succeeded, want errors for %v", tt.want)
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.want) {
+				t.Errorf("errors = %q, want one per %v", lines, tt.want)
+			}
+			for _, setting := range tt.want {
+				if !strings.Contains(err.Error(), setting+":") {
+					t.Errorf("errors = %q, want one for %s", err, setting)
+				}
+			}
+		})
+	}
+}
@@ -1,19 +1,55 @@
 package database
 
 import (
+	"TestTask/internal/config"
 	"TestTask/pkg/logger"
+	"context"
+	"database/sql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"os"
+	"time"
 )
 
 var DB *gorm.DB
 
-func ConnectToDB() {
+// pingTimeout bounds each connection attempt at startup.
+const pingTimeout = 5 * time.Second
+
+// ConnectToDB opens the connection pool to the Postgres database of cfg. Postgres not
+// accepting connections yet, e.g. while it starts next to the service, is retried
+// with backoff before giving up.
+func ConnectToDB(cfg *config.Config) {
 	var err error
-	dsn := os.Getenv("DBurl")
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// The pool is configured before the first connection is made, so skip GORM's ping on open.
+	DB, err = gorm.Open(postgres.Open(cfg.Database.URL), &gorm.Config{Logger: gormLog{}, DisableAutomaticPing: true})
+	if err != nil {
+		logger.Fatal("Failed to connect to DB", "err", err)
+	}
+	sqlDB, err := DB.DB()
 	if err != nil {
-		logger.Logger.Fatal("Failed to connect to DB", err)
+		logger.Fatal("Failed to connect to DB", "err", err)
 	}
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
+	delay := cfg.Database.ConnectBaseDelay
+	for attempt := 1; ; attempt++ {
+		if err = ping(sqlDB); err == nil {
+			return
+		}
+		if attempt >= cfg.Database.ConnectAttempts {
+			logger.Fatal("Failed to connect to DB", "attempts", attempt, "err", err)
+		}
+		logger.Logger.Warn("DB not ready, retrying", "attempt", attempt, "retry_in", delay, "err", err)
+		time.Sleep(delay)
+		delay = min(2*delay, cfg.Database.ConnectMaxDelay)
+	}
+}
+
+func ping(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	return db.PingContext(ctx)
 }
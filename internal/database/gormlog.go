@@ -0,0 +1,56 @@
+package database
+
+import (
+	"TestTask/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"log/slog"
+	"time"
+)
+
+// slowQuery is the duration above which a query is logged at warn level.
+const slowQuery = 200 * time.Millisecond
+
+// gormLog sends GORM's messages to logger.Logger, so queries made with a request context
+// are logged with its request ID. Failed and slow queries are logged at warn level or above,
+// every other query at debug level.
+type gormLog struct{}
+
+func (gormLog) LogMode(gormlogger.LogLevel) gormlogger.Interface { return gormLog{} }
+
+func (gormLog) Info(ctx context.Context, msg string, args ...interface{}) {
+	logger.Logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (gormLog) Warn(ctx context.Context, msg string, args ...interface{}) {
+	logger.Logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (gormLog) Error(ctx context.Context, msg string, args ...interface{}) {
+	logger.Logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (gormLog) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	msg := "Query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, context.Canceled):
+		level, msg = slog.LevelError, "Query failed"
+	case elapsed > slowQuery:
+		level, msg = slog.LevelWarn, "Slow query"
+	}
+	if !logger.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	args := []any{"sql", sql, "rows", rows, "duration", elapsed}
+	if err != nil {
+		args = append(args, "err", err)
+	}
+	logger.Logger.Log(ctx, level, msg, args...)
+}
@@ -1,7 +1,297 @@
 package database
 
-import "TestTask/internal/models"
+import (
+	"TestTask/pkg/logger"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func SyncDB() {
-	DB.AutoMigrate(&models.User{})
+// migrationFiles are the schema migrations, NNNN_name.up.sql and NNNN_name.down.sql.
+// A file starting with the line "-- migrate: no-transaction" runs outside a transaction,
+// one statement at a time, which CREATE INDEX CONCURRENTLY requires; its statements must end
+// with a semicolon at the end of a line, and may contain dollar-quoted bodies such as DO blocks.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock is the key of the advisory lock serializing migrations across instances.
+const migrationLock = 727_274_011
+
+const noTransaction = "-- migrate: no-transaction"
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// dollarQuote matches the $$ or $tag$ delimiters of dollar-quoted strings.
+var dollarQuote = regexp.MustCompile(`\$([A-Za-z_][A-Za-z_0-9]*)?\$`)
+
+// Migration is one schema change and its rollback.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells whether a migration has been applied.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrator applies the embedded migrations and records them in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	log        *slog.Logger
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator for db.
+func NewMigrator(db *sql.DB, log *slog.Logger) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, log: log, migrations: migrations}, nil
+}
+
+func loadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		m := migrationName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		data, err := fs.ReadFile(files, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(data)
+		} else {
+			mig.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int]time.Time) error {
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			m.log.Info("Applying migration", "version", mig.Version, "name", mig.Name)
+			err := run(ctx, conn, mig.Up, func(exec execer) error {
+				_, err := exec.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", mig.Version, mig.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations, newest first, and returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			m.log.Info("Rolling back migration", "version", mig.Version, "name", mig.Name)
+			err := run(ctx, conn, mig.Down, func(exec execer) error {
+				_, err := exec.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", mig.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every known migration and when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(_ *sql.Conn, applied map[int]time.Time) error {
+		for _, mig := range m.migrations {
+			st := MigrationStatus{Version: mig.Version, Name: mig.Name}
+			if at, ok := applied[mig.Version]; ok {
+				st.AppliedAt = &at
+			}
+			statuses = append(statuses, st)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// locked runs fn on one connection holding the migration advisory lock, so concurrent
+// instances starting up migrate one after another, with the applied versions read under the lock.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, applied map[int]time.Time) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLock); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled.
+		if _, unlockErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLock); unlockErr != nil {
+			err = errors.Join(err, fmt.Errorf("release migration lock: %w", unlockErr))
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = at
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return fn(conn, applied)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// run executes script and then record, in one transaction unless the script opts out of it.
+func run(ctx context.Context, conn *sql.Conn, script string, record func(exec execer) error) error {
+	if !strings.HasPrefix(script, noTransaction) {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		if err := record(tx); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	// The version is only recorded once every statement succeeded, and a rerun after a failure
+	// part way executes every statement again. They must be safe to repeat: IF NOT EXISTS is not
+	// enough for CREATE INDEX CONCURRENTLY, whose failed build leaves an INVALID index to drop first.
+	for _, stmt := range statements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return record(conn)
+}
+
+// statements splits script at semicolons ending a line outside dollar-quoted strings.
+func statements(script string) []string {
+	var stmts []string
+	var current strings.Builder
+	quote := "" // the delimiter of the dollar-quoted string the line ends in, if any
+	for _, line := range strings.Split(script, "\n") {
+		current.WriteString(line)
+		current.WriteByte('\n')
+		for _, delim := range dollarQuote.FindAllString(line, -1) {
+			switch quote {
+			case "":
+				quote = delim
+			case delim:
+				quote = ""
+			}
+		}
+		if quote == "" && strings.HasSuffix(strings.TrimSpace(line), ";") {
+			if stmt := strings.TrimSpace(current.String()); stmt != "" {
+				stmts = append(stmts, stmt)
+			}
+			current.Reset()
+		}
+	}
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+// Migrate applies the pending migrations to DB.
+func Migrate(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	m, err := NewMigrator(sqlDB, logger.Logger)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(ctx)
+	return err
 }
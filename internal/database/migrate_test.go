@@ -0,0 +1,158 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"empty", "", nil},
+		{
+			"one per line",
+			"CREATE INDEX a ON t (x);\nCREATE INDEX b ON t (y);\n",
+			[]string{"CREATE INDEX a ON t (x);", "CREATE INDEX b ON t (y);"},
+		},
+		{
+			"statement over several lines",
+			"CREATE INDEX a\n    ON t (x);\nDROP INDEX b;",
+			[]string{"CREATE INDEX a\n    ON t (x);", "DROP INDEX b;"},
+		},
+		{
+			"semicolon inside a line",
+			"SELECT 1; SELECT 2\n;\n",
+			[]string{"SELECT 1; SELECT 2\n;"},
+		},
+		{
+			"missing final semicolon",
+			"DROP INDEX a;\nDROP INDEX b\n",
+			[]string{"DROP INDEX a;", "DROP INDEX b"},
+		},
+		{
+			"do block",
+			"DO $$\nBEGIN\n    DROP INDEX a;\nEND\n$$;\nCREATE INDEX a ON t (x);\n",
+			[]string{"DO $$\nBEGIN\n    DROP INDEX a;\nEND\n$$;", "CREATE INDEX a ON t (x);"},
+		},
+		{
+			"do block on one line",
+			"DO $$ BEGIN DROP INDEX a; END $$;\nDROP INDEX b;\n",
+			[]string{"DO $$ BEGIN DROP INDEX a; END $$;", "DROP INDEX b;"},
+		},
+		{
+			"tagged quote containing $$",
+			"DO $body$\nBEGIN\n    EXECUTE $$DROP INDEX a$$;\nEND\n$body$;\nDROP INDEX b;\n",
+			[]string{"DO $body$\nBEGIN\n    EXECUTE $$DROP INDEX a$$;\nEND\n$body$;", "DROP INDEX b;"},
+		},
+		{
+			"positional parameters are not quotes",
+			"PREPARE p AS SELECT $1, $2;\nDROP INDEX a;\n",
+			[]string{"PREPARE p AS SELECT $1, $2;", "DROP INDEX a;"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(data string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(data)} }
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int
+		wantErr  string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"migrations/0010_later.up.sql":    file("up 10"),
+				"migrations/0010_later.down.sql":  file("down 10"),
+				"migrations/0002_second.up.sql":   file("up 2"),
+				"migrations/0002_second.down.sql": file("down 2"),
+			},
+			versions: []int{2, 10},
+		},
+		{
+			name:    "no migrations directory",
+			files:   fstest.MapFS{},
+			wantErr: "migrations",
+		},
+		{
+			name:    "bad file name",
+			files:   fstest.MapFS{"migrations/initial.sql": file("up")},
+			wantErr: "name must be",
+		},
+		{
+			name:    "missing down",
+			files:   fstest.MapFS{"migrations/0001_initial.up.sql": file("up")},
+			wantErr: "needs both",
+		},
+		{
+			name:    "empty up",
+			files:   fstest.MapFS{"migrations/0001_initial.up.sql": file(""), "migrations/0001_initial.down.sql": file("down")},
+			wantErr: "needs both",
+		},
+		{
+			name:    "two names",
+			files:   fstest.MapFS{"migrations/0001_initial.up.sql": file("up"), "migrations/0001_first.down.sql": file("down")},
+			wantErr: "two names",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var versions []int
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+				if m.Up == "" || m.Down == "" {
+					t.Errorf("migration %d is missing a script", m.Version)
+				}
+			}
+			if !reflect.DeepEqual(versions, tt.versions) {
+				t.Errorf("versions = %v, want %v", versions, tt.versions)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s: versions must be consecutive from 1", m.Version, m.Name)
+		}
+		for _, script := range []string{m.Up, m.Down} {
+			if !strings.HasPrefix(script, noTransaction) {
+				continue
+			}
+			// Every statement of a no-transaction script must be complete on its own.
+			for _, stmt := range statements(script) {
+				if !strings.HasSuffix(stmt, ";") {
+					t.Errorf("migration %d_%s: statement does not end with a semicolon: %q", m.Version, m.Name, stmt)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS enrichment_jobs;
+DROP TABLE IF EXISTS enrichment_cache;
+DROP TABLE IF EXISTS user_enrichments;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,63 @@
+-- Schema previously created by GORM AutoMigrate. Everything is conditional, so databases
+-- created by AutoMigrate, including ones from before a column was added, are adopted as is.
+
+CREATE TABLE IF NOT EXISTS users (
+    id           bigserial PRIMARY KEY,
+    created_at   timestamptz,
+    updated_at   timestamptz,
+    name         text,
+    surname      text,
+    age          bigint,
+    gender       text,
+    nationality  text
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS enrichment_status text DEFAULT 'succeeded';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS enrichment_error text;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_users_enrichment_status ON users (enrichment_status);
+
+CREATE TABLE IF NOT EXISTS user_enrichments (
+    id                       bigserial PRIMARY KEY,
+    created_at               timestamptz,
+    updated_at               timestamptz,
+    user_id                  bigint,
+    age_provider             text,
+    age_count                bigint,
+    gender_provider          text,
+    gender_probability       decimal,
+    gender_count             bigint,
+    nationality_provider     text,
+    nationality_probability  decimal,
+    nationality_count        bigint,
+    nationality_candidates   text,
+    enriched_at              timestamptz,
+    CONSTRAINT fk_users_enrichment FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_enrichments_user_id ON user_enrichments (user_id);
+
+CREATE TABLE IF NOT EXISTS enrichment_cache (
+    name        text PRIMARY KEY,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    value       jsonb,
+    expires_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_enrichment_cache_expires_at ON enrichment_cache (expires_at);
+
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    id            bigserial PRIMARY KEY,
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    user_id       bigint,
+    status        text,
+    attempts      bigint,
+    max_attempts  bigint,
+    next_run_at   timestamptz,
+    locked_at     timestamptz,
+    last_error    text
+);
+ALTER TABLE enrichment_jobs ADD COLUMN IF NOT EXISTS request_id text;
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_user_id ON enrichment_jobs (user_id);
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_status_next_run ON enrichment_jobs (status, next_run_at);
@@ -0,0 +1,3 @@
+-- The pg_trgm extension is left installed, other objects may depend on it.
+DROP INDEX IF EXISTS idx_users_surname_trgm;
+DROP INDEX IF EXISTS idx_users_name_trgm;
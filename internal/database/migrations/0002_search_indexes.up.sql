@@ -0,0 +1,4 @@
+-- Case-insensitive prefix and fuzzy name search.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_surname_trgm ON users USING gin (surname gin_trgm_ops);
@@ -0,0 +1,4 @@
+-- migrate: no-transaction
+DROP INDEX CONCURRENTLY IF EXISTS idx_users_age;
+DROP INDEX CONCURRENTLY IF EXISTS idx_users_nationality;
+DROP INDEX CONCURRENTLY IF EXISTS idx_users_gender;
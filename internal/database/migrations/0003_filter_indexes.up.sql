@@ -0,0 +1,22 @@
+-- migrate: no-transaction
+-- Indexes for the gender, nationality and age filters of the users list. They only cover
+-- users that are not deleted, like the list does by default, and are built concurrently
+-- so writes are not blocked on large tables.
+-- A concurrent build that fails leaves an INVALID index behind, which IF NOT EXISTS would
+-- then skip; drop such leftovers first so a rerun builds them again.
+DO $$
+DECLARE
+    idx regclass;
+BEGIN
+    FOR idx IN
+        SELECT indexrelid::regclass FROM pg_index
+        WHERE indrelid = 'users'::regclass AND NOT indisvalid
+          AND indexrelid::regclass::text IN ('idx_users_gender', 'idx_users_nationality', 'idx_users_age')
+    LOOP
+        EXECUTE format('DROP INDEX %s', idx);
+    END LOOP;
+END
+$$;
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_gender ON users (gender) WHERE deleted_at IS NULL;
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_nationality ON users (nationality) WHERE deleted_at IS NULL;
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_users_age ON users (age) WHERE deleted_at IS NULL;
@@ -0,0 +1,33 @@
+package enrichment
+
+import (
+	"TestTask/internal/models"
+	"TestTask/pkg/enrich"
+)
+
+// Apply copies the enriched attributes and their metadata onto user and marks it as enriched.
+func Apply(user *models.User, enriched *enrich.Enriched) {
+	user.Age = enriched.Age
+	user.Gender = enriched.Gender
+	user.Nationality = enriched.Nationality
+	user.EnrichmentStatus = models.EnrichmentSucceeded
+	user.EnrichmentError = ""
+
+	candidates := make([]models.NationalityCandidate, 0, len(enriched.Countries))
+	for _, c := range enriched.Countries {
+		candidates = append(candidates, models.NationalityCandidate{CountryID: c.CountryID, Probability: c.Probability})
+	}
+	user.Enrichment = &models.UserEnrichment{
+		UserID:                 user.ID,
+		AgeProvider:            enriched.AgeProvider,
+		AgeCount:               enriched.AgeCount,
+		GenderProvider:         enriched.GenderProvider,
+		GenderProbability:      enriched.GenderProbability,
+		GenderCount:            enriched.GenderCount,
+		NationalityProvider:    enriched.NationalityProvider,
+		NationalityProbability: enriched.NationalityProbability,
+		NationalityCount:       enriched.NationalityCount,
+		NationalityCandidates:  candidates,
+		EnrichedAt:             enriched.EnrichedAt,
+	}
+}
@@ -0,0 +1,266 @@
+package enrichment
+
+import (
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"TestTask/pkg/enrich"
+	"TestTask/pkg/logger"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrNotStarted is returned when a job is submitted while the worker pool is not running.
+var ErrNotStarted = errors.New("enrichment workers are not running")
+
+// Options configures the background enrichment workers.
+type Options struct {
+	Workers int
+	// Attempts is the total number of tries per job; Backoff is doubled after each failed try
+	// up to MaxBackoff.
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// PollInterval is how often idle workers look for due jobs in the queue table.
+	PollInterval time.Duration
+	// LockTimeout is how long a job may stay running before it is considered abandoned and requeued.
+	LockTimeout time.Duration
+
+	Jobs     repository.JobRepository
+	Users    repository.UserRepository
+	Enricher Enricher
+	Log      *slog.Logger
+}
+
+// Enricher looks up the attributes of a first name.
+type Enricher interface {
+	Enrich(ctx context.Context, name string) (*enrich.Enriched, error)
+}
+
+// Pool runs the in-process workers that drain the enrichment job queue.
+type Pool struct {
+	opts Options
+	wake chan struct{}
+	// quit is closed to stop claiming jobs; cancelling ctx also interrupts the jobs in flight.
+	quit     chan struct{}
+	quitOnce sync.Once
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// StartWorkers launches the workers and returns the running pool.
+func StartWorkers(opts Options) *Pool {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.Attempts <= 0 {
+		opts.Attempts = 1
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Log == nil {
+		opts.Log = slog.Default()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		opts:   opts,
+		wake:   make(chan struct{}, opts.Workers),
+		quit:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < opts.Workers; i++ {
+		p.wg.Add(1)
+		go p.run()
+	}
+	if opts.LockTimeout > 0 {
+		p.wg.Add(1)
+		go p.reap()
+	}
+	opts.Log.Info("Started enrichment workers", "workers", opts.Workers)
+	return p
+}
+
+// Shutdown stops the workers from claiming jobs and waits for the jobs in flight to finish.
+// If ctx is done first, the remaining jobs are cancelled and ctx's error is returned; they stay
+// running in the table until the lock timeout requeues them.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.quitOnce.Do(func() { close(p.quit) })
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// CreatePending stores user as pending together with its enrichment job.
+// It returns ErrNotStarted once the pool is shutting down.
+func (p *Pool) CreatePending(ctx context.Context, user *models.User) error {
+	if p.stopping() {
+		return ErrNotStarted
+	}
+	user.EnrichmentStatus = models.EnrichmentPending
+	if _, err := p.opts.Jobs.CreateWithJob(ctx, user, p.opts.Attempts); err != nil {
+		return err
+	}
+	p.Notify()
+	return nil
+}
+
+// Notify wakes an idle worker, e.g. after a job was requeued through the admin API.
+func (p *Pool) Notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Pool) run() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		// Drain every due job before going idle.
+		for !p.stopping() {
+			job, err := p.opts.Jobs.Claim(p.ctx)
+			if err != nil {
+				p.opts.Log.Error("Could not claim enrichment job", "err", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			p.process(job)
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.quit:
+			return
+		case <-p.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Pool) stopping() bool {
+	select {
+	case <-p.quit:
+		return true
+	default:
+		return p.ctx.Err() != nil
+	}
+}
+
+func (p *Pool) reap() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.opts.LockTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.quit:
+			return
+		case <-ticker.C:
+		}
+		n, err := p.opts.Jobs.ReleaseStale(p.ctx, time.Now().Add(-p.opts.LockTimeout))
+		if err != nil {
+			p.opts.Log.Error("Could not release stale enrichment jobs", "err", err)
+			continue
+		}
+		if n > 0 {
+			p.opts.Log.Warn("Requeued stale enrichment jobs", "jobs", n)
+			p.Notify()
+		}
+	}
+}
+
+func (p *Pool) process(job *models.EnrichmentJob) {
+	// Log lines of the job carry the request ID of the request that queued it.
+	ctx := logger.WithRequestID(p.ctx, job.RequestID)
+	log := p.opts.Log.With("job_id", job.ID, "user_id", job.UserID, "attempt", job.Attempts)
+
+	user, err := p.opts.Users.GetByID(ctx, job.UserID)
+	if err != nil {
+		log.ErrorContext(ctx, "Could not load user for enrichment", "err", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			// The user was deleted, retrying cannot help.
+			job.Attempts = job.MaxAttempts
+		}
+		p.fail(ctx, log, job, err)
+		return
+	}
+
+	base := *user
+	enriched, err := p.opts.Enricher.Enrich(ctx, user.Name)
+	if err == nil {
+		Apply(user, enriched)
+		err = p.opts.Users.SaveEnrichment(ctx, &base, user)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		// The user was deleted during the lookup.
+		job.Attempts = job.MaxAttempts
+	}
+	if err == nil {
+		// The enrichment is saved, so record it even if shutdown cancels the pool meanwhile.
+		if err := p.opts.Jobs.Complete(context.WithoutCancel(ctx), job.ID); err != nil {
+			log.ErrorContext(ctx, "Could not mark enrichment job as succeeded", "err", err)
+		}
+		log.InfoContext(ctx, "User enriched")
+		return
+	}
+	if p.ctx.Err() != nil {
+		// Shutting down: the job is requeued once its lock expires.
+		return
+	}
+	p.fail(ctx, log, job, err)
+}
+
+// fail reschedules the job with exponential backoff or dead-letters it once attempts are exhausted.
+func (p *Pool) fail(ctx context.Context, log *slog.Logger, job *models.EnrichmentJob, err error) {
+	ctx = context.WithoutCancel(ctx)
+	if job.Attempts < job.MaxAttempts {
+		delay := p.opts.retryDelay(job.Attempts)
+		log.WarnContext(ctx, "Enrichment job failed, retrying", "retry_in", delay, "err", err)
+		if rsErr := p.opts.Jobs.Reschedule(ctx, job.ID, time.Now().Add(delay), err.Error()); rsErr != nil {
+			log.ErrorContext(ctx, "Could not reschedule enrichment job", "err", rsErr)
+		}
+		return
+	}
+
+	log.ErrorContext(ctx, "Enrichment job is dead", "err", err)
+	if dlErr := p.opts.Jobs.DeadLetter(ctx, job, err.Error()); dlErr != nil {
+		log.ErrorContext(ctx, "Could not dead-letter enrichment job", "err", dlErr)
+	}
+}
+
+// retryDelay returns the wait before the next try after attempt failed tries, capped at MaxBackoff.
+func (o Options) retryDelay(attempt int) time.Duration {
+	delay := min(o.Backoff, o.MaxBackoff)
+	for i := 1; i < attempt && delay < o.MaxBackoff; i++ {
+		// Checked before doubling so that a large MaxBackoff cannot overflow.
+		if delay > o.MaxBackoff/2 {
+			return o.MaxBackoff
+		}
+		delay *= 2
+	}
+	return delay
+}
@@ -0,0 +1,32 @@
+package enrichment
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		attempt int
+		want    time.Duration
+	}{
+		{"first retry", Options{Backoff: 5 * time.Second, MaxBackoff: time.Hour}, 1, 5 * time.Second},
+		{"doubled", Options{Backoff: 5 * time.Second, MaxBackoff: time.Hour}, 3, 20 * time.Second},
+		{"capped", Options{Backoff: 5 * time.Second, MaxBackoff: time.Hour}, 11, time.Hour},
+		{"capped at the last doubling", Options{Backoff: 5 * time.Second, MaxBackoff: time.Minute}, 5, time.Minute},
+		{"many attempts", Options{Backoff: 5 * time.Second, MaxBackoff: time.Hour}, 1000, time.Hour},
+		{"no overflow", Options{Backoff: time.Second, MaxBackoff: math.MaxInt64}, 100, math.MaxInt64},
+		{"backoff above the cap", Options{Backoff: 2 * time.Hour, MaxBackoff: time.Hour}, 1, time.Hour},
+		{"no backoff", Options{MaxBackoff: time.Hour}, 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.retryDelay(tt.attempt); got != tt.want {
+				t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"TestTask/pkg/enrich"
+	"encoding/json"
+	"net/http"
+)
+
+// GetEnrichmentCacheStats godoc
+// @Summary      Статистика кэша обогащения
+// @Description  Количество попаданий и промахов кэша обогащения с момента запуска
+// @Tags         enrichment
+// @Produce      json
+// @Success      200  {object}  enrich.CacheStats
+// @Router       /enrichment/cache [get]
+func GetEnrichmentCacheStats(e *enrich.Reloadable) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(e.CacheStats())
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// readyTimeout bounds the database ping of the readiness probe.
+const readyTimeout = 2 * time.Second
+
+// Ready godoc
+// @Summary      Проверка готовности
+// @Description  200, если база данных отвечает, иначе 503. Для readiness-проб балансировщика или Kubernetes.
+// @Tags         health
+// @Produce      plain
+// @Success      200  {string}  string "ok"
+// @Failure      503  {string}  string "database unavailable"
+// @Router       /readyz [get]
+func Ready(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+		if err := db.PingContext(ctx); err != nil {
+			http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	}
+}
+
+// Metrics godoc
+// @Summary      Метрики
+// @Description  Статистика пула соединений с базой данных в текстовом формате Prometheus
+// @Tags         health
+// @Produce      plain
+// @Success      200  {string}  string
+// @Router       /metrics [get]
+func Metrics(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := db.Stats()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, m := range []struct {
+			name, kind, help string
+			value            float64
+		}{
+			{"db_pool_max_open_connections", "gauge", "Maximum number of open connections, 0 for no limit.", float64(s.MaxOpenConnections)},
+			{"db_pool_open_connections", "gauge", "Established connections, in use and idle.", float64(s.OpenConnections)},
+			{"db_pool_in_use_connections", "gauge", "Connections currently in use.", float64(s.InUse)},
+			{"db_pool_idle_connections", "gauge", "Idle connections.", float64(s.Idle)},
+			{"db_pool_wait_count_total", "counter", "Connections waited for because the pool was exhausted.", float64(s.WaitCount)},
+			{"db_pool_wait_duration_seconds_total", "counter", "Time spent waiting for a connection.", s.WaitDuration.Seconds()},
+			{"db_pool_max_idle_closed_total", "counter", "Connections closed because of max_idle_conns.", float64(s.MaxIdleClosed)},
+			{"db_pool_max_idle_time_closed_total", "counter", "Connections closed because of conn_max_idle_time.", float64(s.MaxIdleTimeClosed)},
+			{"db_pool_max_lifetime_closed_total", "counter", "Connections closed because of conn_max_lifetime.", float64(s.MaxLifetimeClosed)},
+		} {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", m.name, m.help, m.name, m.kind, m.name, m.value)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"TestTask/internal/api/query"
+	"TestTask/internal/enrichment"
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// JobsHandler serves the admin API of the enrichment job queue.
+type JobsHandler struct {
+	jobs repository.JobRepository
+	// workers is woken when a job is requeued; nil when the workers run in another process.
+	workers *enrichment.Pool
+	log     *slog.Logger
+}
+
+// NewJobsHandler returns a JobsHandler managing jobs and waking workers when one is requeued.
+func NewJobsHandler(jobs repository.JobRepository, workers *enrichment.Pool, log *slog.Logger) *JobsHandler {
+	return &JobsHandler{jobs: jobs, workers: workers, log: log}
+}
+
+// GetJobs godoc
+// @Summary      Список задач обогащения
+// @Description  Получить задачи обогащения, новые первыми, с фильтром по статусу
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Param        status  query  string  false  "Статус (queued, running, succeeded, dead, cancelled)"
+// @Param        page    query  int     false  "Номер страницы"
+// @Param        limit   query  int     false  "Количество на странице (не больше 100)"
+// @Success      200  {array}   models.EnrichmentJob
+// @Failure      400  {string}  string "Bad request"
+// @Failure      500  {string}  string "Internal Server Error"
+// @Failure      401  {string}  string "Invalid or missing admin token"
+// @Failure      403  {string}  string "Admin endpoints are only served to localhost"
+// @Router       /admin/jobs [get]
+func (h *JobsHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	status, page, limit, err := parseJobsQuery(r.URL.Query())
+	if err != nil {
+		h.log.WarnContext(r.Context(), "Invalid jobs query", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jobs, err := h.jobs.List(r.Context(), status, page, limit)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Could not retrieve jobs", "err", err)
+		http.Error(w, "Failed to retrieve jobs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// Page sizes of the jobs list.
+const (
+	defaultJobsLimit = 10
+	maxJobsLimit     = 100
+)
+
+func parseJobsQuery(q url.Values) (status string, page, limit int, err error) {
+	p := query.NewParser(q)
+	status = p.OneOf("status", models.JobQueued, models.JobRunning, models.JobSucceeded, models.JobDead, models.JobCancelled)
+	page, limit = 1, defaultJobsLimit
+	if v := p.Int("page"); v != nil {
+		page = *v
+	}
+	if v := p.Int("limit"); v != nil {
+		limit = *v
+	}
+	switch {
+	case p.Err() != nil:
+		return "", 0, 0, p.Err()
+	case page < 1:
+		return "", 0, 0, fmt.Errorf("page must be positive")
+	case limit < 1 || limit > maxJobsLimit:
+		return "", 0, 0, fmt.Errorf("limit must be between 1 and %d", maxJobsLimit)
+	}
+	return status, page, limit, nil
+}
+
+// RetryJob godoc
+// @Summary      Повтор задачи обогащения
+// @Description  Вернуть мёртвую или отменённую задачу в очередь с новым запасом попыток
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Param        id  path  int  true  "ID задачи"
+// @Success      200  {object}  models.EnrichmentJob
+// @Failure      400  {string}  string "Bad request"
+// @Failure      404  {string}  string "Job not found"
+// @Failure      409  {string}  string "Job cannot be retried"
+// @Failure      401  {string}  string "Invalid or missing admin token"
+// @Failure      403  {string}  string "Admin endpoints are only served to localhost"
+// @Router       /admin/jobs/{id}/retry [post]
+func (h *JobsHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	h.changeJob(w, r, "retry", "Job cannot be retried", h.jobs.Retry)
+}
+
+// CancelJob godoc
+// @Summary      Отмена задачи обогащения
+// @Description  Отменить задачу, ожидающую в очереди
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Param        id  path  int  true  "ID задачи"
+// @Success      200  {object}  models.EnrichmentJob
+// @Failure      400  {string}  string "Bad request"
+// @Failure      404  {string}  string "Job not found"
+// @Failure      409  {string}  string "Job cannot be cancelled"
+// @Failure      401  {string}  string "Invalid or missing admin token"
+// @Failure      403  {string}  string "Admin endpoints are only served to localhost"
+// @Router       /admin/jobs/{id}/cancel [post]
+func (h *JobsHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	h.changeJob(w, r, "cancel", "Job cannot be cancelled", h.jobs.Cancel)
+}
+
+func (h *JobsHandler) changeJob(w http.ResponseWriter, r *http.Request, action, conflictMsg string, change func(ctx context.Context, id uint) (*models.EnrichmentJob, error)) {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		h.log.WarnContext(r.Context(), "Invalid job id")
+		http.Error(w, "Invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	job, err := change(r.Context(), uint(id))
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		h.log.WarnContext(r.Context(), "Job not found", "job_id", id)
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	case errors.Is(err, repository.ErrJobState):
+		h.log.WarnContext(r.Context(), "Could not change job", "action", action, "job_id", id, "err", err)
+		http.Error(w, conflictMsg, http.StatusConflict)
+		return
+	case err != nil:
+		h.log.ErrorContext(r.Context(), "Could not change job", "action", action, "job_id", id, "err", err)
+		http.Error(w, "Could not update job", http.StatusInternalServerError)
+		return
+	}
+	if job.Status == models.JobQueued && h.workers != nil {
+		h.workers.Notify()
+	}
+
+	h.log.InfoContext(r.Context(), "Job changed", "action", action, "job_id", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseJobsQuery(t *testing.T) {
+	tests := []struct {
+		query       string
+		status      string
+		page, limit int
+		wantErr     bool
+	}{
+		{"", "", 1, 10, false},
+		{"status=dead&page=3&limit=100", "dead", 3, 100, false},
+		{"limit=abc", "", 0, 0, true},
+		{"limit=0", "", 0, 0, true},
+		{"limit=101", "", 0, 0, true},
+		{"page=0", "", 0, 0, true},
+		{"page=-1", "", 0, 0, true},
+		{"status=lost", "", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			status, page, limit, err := parseJobsQuery(q)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (status != tt.status || page != tt.page || limit != tt.limit) {
+				t.Errorf("got (%q, %d, %d), want (%q, %d, %d)", status, page, limit, tt.status, tt.page, tt.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"TestTask/internal/reload"
+	"encoding/json"
+	"net/http"
+)
+
+// GetConfigReload godoc
+// @Summary      Статус перезагрузки конфигурации
+// @Description  Результат последней перезагрузки настроек обогащения (по SIGHUP, изменению файла или запросу)
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object}  reload.Status
+// @Failure      401  {string}  string "Invalid or missing admin token"
+// @Failure      403  {string}  string "Admin endpoints are only served to localhost"
+// @Router       /admin/config/reload [get]
+func GetConfigReload(rl *reload.Reloader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rl.Status())
+	}
+}
+
+// ReloadConfig godoc
+// @Summary      Перезагрузка конфигурации
+// @Description  Перечитать конфигурацию и применить настройки обогащения без перезапуска. Некорректная конфигурация не применяется.
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object}  reload.Status
+// @Failure      422  {object}  reload.Status "Invalid configuration, current settings kept"
+// @Failure      401  {string}  string "Invalid or missing admin token"
+// @Failure      403  {string}  string "Admin endpoints are only served to localhost"
+// @Router       /admin/config/reload [post]
+func ReloadConfig(rl *reload.Reloader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := rl.Reload(reload.TriggerAPI)
+		w.Header().Set("Content-Type", "application/json")
+		if !status.Success {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+		json.NewEncoder(w).Encode(status)
+	}
+}
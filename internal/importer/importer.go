@@ -0,0 +1,283 @@
+package importer
+
+import (
+	"TestTask/internal/enrichment"
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"TestTask/pkg/enrich"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Supported input formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+const (
+	DefaultConcurrency = 4
+	DefaultBatchSize   = 100
+)
+
+// Enricher looks up the attributes of a first name.
+type Enricher interface {
+	Enrich(ctx context.Context, name string) (*enrich.Enriched, error)
+}
+
+// Options tunes an import. Zero values fall back to the defaults above;
+// Users and Enricher are required.
+type Options struct {
+	Concurrency int
+	BatchSize   int
+
+	Users    repository.UserRepository
+	Enricher Enricher
+}
+
+// RowResult is the outcome of one input row. Line is the 1-based line of the row in the input.
+type RowResult struct {
+	Line    int
+	Name    string
+	Surname string
+	UserID  uint   `json:",omitempty"`
+	Error   string `json:",omitempty"`
+}
+
+// Report summarizes an import.
+type Report struct {
+	Total    int
+	Imported int
+	Failed   int
+	Rows     []RowResult
+}
+
+type row struct {
+	line    int
+	name    string
+	surname string
+	err     error
+}
+
+type enrichedRow struct {
+	result *RowResult
+	user   *models.User
+}
+
+// Import reads users from r, enriches them with bounded concurrency and inserts them in batches.
+// Rows that fail validation, enrichment or insertion are reported and skipped; the returned error
+// is only set when the input itself cannot be read.
+func Import(ctx context.Context, r io.Reader, format string, opts Options) (*Report, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	var read func(ctx context.Context, r io.Reader, rows chan<- row) error
+	switch format {
+	case FormatCSV:
+		read = readCSV
+	case FormatNDJSON:
+		read = readNDJSON
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rows := make(chan row)
+	var readErr error
+	go func() {
+		defer close(rows)
+		readErr = read(ctx, r, rows)
+	}()
+
+	enriched := make(chan enrichedRow)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rw := range rows {
+				enriched <- enrichRow(ctx, opts.Enricher, rw)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(enriched)
+	}()
+
+	var results []*RowResult
+	batch := make([]enrichedRow, 0, opts.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		users := make([]*models.User, len(batch))
+		for i, er := range batch {
+			users[i] = er.user
+		}
+		err := opts.Users.CreateBatch(ctx, users, opts.BatchSize)
+		for _, er := range batch {
+			if err != nil {
+				er.result.Error = "could not save user: " + err.Error()
+			} else {
+				er.result.UserID = er.user.ID
+			}
+		}
+		batch = batch[:0]
+	}
+	for er := range enriched {
+		results = append(results, er.result)
+		if er.user == nil {
+			continue
+		}
+		batch = append(batch, er)
+		if len(batch) == opts.BatchSize {
+			flush()
+		}
+	}
+	flush()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+	report := &Report{Total: len(results), Rows: make([]RowResult, 0, len(results))}
+	for _, res := range results {
+		if res.Error != "" {
+			report.Failed++
+		} else {
+			report.Imported++
+		}
+		report.Rows = append(report.Rows, *res)
+	}
+	if readErr != nil {
+		return report, readErr
+	}
+	return report, ctx.Err()
+}
+
+func enrichRow(ctx context.Context, enricher Enricher, rw row) enrichedRow {
+	res := &RowResult{Line: rw.line, Name: rw.name, Surname: rw.surname}
+	if rw.err != nil {
+		res.Error = rw.err.Error()
+		return enrichedRow{result: res}
+	}
+	if rw.name == "" || rw.surname == "" {
+		res.Error = "name and surname are required"
+		return enrichedRow{result: res}
+	}
+
+	enriched, err := enricher.Enrich(ctx, rw.name)
+	if err != nil {
+		res.Error = "enrichment failed: " + err.Error()
+		return enrichedRow{result: res}
+	}
+	user := &models.User{Name: rw.name, Surname: rw.surname}
+	enrichment.Apply(user, enriched)
+	return enrichedRow{result: res, user: user}
+}
+
+// readCSV expects name and surname columns. A first row containing "name" and "surname"
+// is treated as a header and may put them in any position; otherwise they are the first two columns.
+func readCSV(ctx context.Context, r io.Reader, rows chan<- row) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	nameCol, surnameCol := 0, 1
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if !send(ctx, rows, row{line: parseErr.StartLine, err: err}) {
+				return ctx.Err()
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if first {
+			if n, s, ok := csvHeader(record); ok {
+				nameCol, surnameCol = n, s
+				continue
+			}
+		}
+		line, _ := cr.FieldPos(0)
+		rw := row{line: line}
+		if nameCol < len(record) {
+			rw.name = strings.TrimSpace(record[nameCol])
+		}
+		if surnameCol < len(record) {
+			rw.surname = strings.TrimSpace(record[surnameCol])
+		}
+		if !send(ctx, rows, rw) {
+			return ctx.Err()
+		}
+	}
+}
+
+func csvHeader(record []string) (nameCol, surnameCol int, ok bool) {
+	nameCol, surnameCol = -1, -1
+	for i, col := range record {
+		switch strings.ToLower(strings.TrimSpace(col)) {
+		case "name":
+			nameCol = i
+		case "surname":
+			surnameCol = i
+		}
+	}
+	return nameCol, surnameCol, nameCol >= 0 && surnameCol >= 0
+}
+
+// readNDJSON expects one {"name": ..., "surname": ...} object per line. Blank lines are skipped.
+func readNDJSON(ctx context.Context, r io.Reader, rows chan<- row) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := bytes.TrimSpace(sc.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var body struct {
+			Name    string
+			Surname string
+		}
+		rw := row{line: line}
+		if err := json.Unmarshal(text, &body); err != nil {
+			rw.err = fmt.Errorf("invalid JSON: %w", err)
+		} else {
+			rw.name = strings.TrimSpace(body.Name)
+			rw.surname = strings.TrimSpace(body.Surname)
+		}
+		if !send(ctx, rows, rw) {
+			return ctx.Err()
+		}
+	}
+	return sc.Err()
+}
+
+func send(ctx context.Context, rows chan<- row, rw row) bool {
+	select {
+	case rows <- rw:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// UserEnrichment keeps the confidence and provenance of the enriched attributes of a user.
+type UserEnrichment struct {
+	ID                     uint `gorm:"primarykey"`
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	UserID                 uint `gorm:"uniqueIndex"`
+	AgeProvider            string
+	AgeCount               int
+	GenderProvider         string
+	GenderProbability      float64
+	GenderCount            int
+	NationalityProvider    string
+	NationalityProbability float64
+	NationalityCount       int
+	NationalityCandidates  []NationalityCandidate `gorm:"serializer:json"`
+	EnrichedAt             time.Time
+}
+
+// NationalityCandidate is one of the ranked countries returned by the nationality provider.
+type NationalityCandidate struct {
+	CountryID   string
+	Probability float64
+}
+
+// EnrichmentCacheEntry is a cached enrichment result for a normalized first name.
+type EnrichmentCacheEntry struct {
+	Name      string `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Value     string     `gorm:"type:jsonb"`
+	ExpiresAt *time.Time `gorm:"index"`
+}
+
+func (EnrichmentCacheEntry) TableName() string {
+	return "enrichment_cache"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// Enrichment job statuses. Dead jobs exhausted their attempts and wait for a manual retry.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+	JobCancelled = "cancelled"
+)
+
+// EnrichmentJob is a durable request to enrich a user, processed by the background workers.
+type EnrichmentJob struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uint   `gorm:"index"`
+	Status      string `gorm:"index:idx_enrichment_jobs_status_next_run"`
+	Attempts    int
+	MaxAttempts int
+	NextRunAt   time.Time `gorm:"index:idx_enrichment_jobs_status_next_run"`
+	LockedAt    *time.Time
+	LastError   string
+	// RequestID is the ID of the API request that queued the job, for correlating the worker logs.
+	RequestID string
+}
@@ -1,17 +1,43 @@
 package models
 
 import (
+	"gorm.io/gorm"
 	"time"
 )
 
+// Enrichment statuses of a user.
+const (
+	EnrichmentPending   = "pending"
+	EnrichmentSucceeded = "succeeded"
+	EnrichmentFailed    = "failed"
+)
+
+// Genders a user can have; an empty Gender is unknown.
+const (
+	GenderMale   = "male"
+	GenderFemale = "female"
+)
+
+// ValidGender reports whether gender is one of the genders above or empty.
+func ValidGender(gender string) bool {
+	return gender == "" || gender == GenderMale || gender == GenderFemale
+}
+
 type User struct {
 	ID          uint `gorm:"primarykey"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
-	DeletedAt   *time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
 	Name        string
 	Surname     string
 	Age         int
 	Gender      string
 	Nationality string
+	Enrichment  *UserEnrichment `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+
+	EnrichmentStatus string `gorm:"default:succeeded;index"`
+	EnrichmentError  string
+
+	// Version is incremented by every update and exposed as the ETag of the user.
+	Version uint `gorm:"not null;default:1"`
 }
@@ -0,0 +1,167 @@
+// Package reload applies changes of the enrichment settings to the running service.
+// A reload re-reads the whole configuration, with the same precedence as at startup,
+// validates it and swaps the url, providers, timeouts, retry and breaker sections into
+// the enricher. Other sections still need a restart.
+package reload
+
+import (
+	"TestTask/internal/config"
+	"TestTask/pkg/enrich"
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Reload triggers.
+const (
+	TriggerSignal = "sighup"
+	TriggerFile   = "file"
+	TriggerAPI    = "api"
+)
+
+// reloadable are the YAML names of the sections applied by a reload.
+var reloadable = map[string]bool{"url": true, "providers": true, "timeouts": true, "retry": true, "breaker": true}
+
+// Status describes the last reload attempt.
+type Status struct {
+	// Trigger, At, Success and Error describe the last attempt; they are empty before the first one.
+	Trigger string    `json:"trigger,omitempty"`
+	At      time.Time `json:"at"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+	// Reloads counts the successful reloads since startup; LoadedAt is when the active settings were applied.
+	Reloads  int       `json:"reloads"`
+	LoadedAt time.Time `json:"loaded_at"`
+	// RestartRequired lists the changed sections a reload cannot apply.
+	RestartRequired []string `json:"restart_required,omitempty"`
+}
+
+// Reloader reloads the settings of an enricher.
+type Reloader struct {
+	load     func() (*config.Config, error)
+	enricher *enrich.Reloadable
+	startup  *config.Config
+	log      *slog.Logger
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New returns a Reloader applying the configuration returned by load to enricher.
+// startup is the configuration the service was started with.
+func New(load func() (*config.Config, error), enricher *enrich.Reloadable, startup *config.Config, log *slog.Logger) *Reloader {
+	return &Reloader{
+		load:     load,
+		enricher: enricher,
+		startup:  startup,
+		log:      log,
+		status:   Status{LoadedAt: time.Now()},
+	}
+}
+
+// Status returns the outcome of the last reload.
+func (r *Reloader) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Reload loads the configuration and applies it. An invalid configuration is not applied
+// and the enricher keeps its current settings.
+func (r *Reloader) Reload(trigger string) Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := r.status
+	st.Trigger, st.At = trigger, time.Now()
+	st.Success, st.Error, st.RestartRequired = false, "", nil
+
+	cfg, err := r.load()
+	if err == nil {
+		err = r.enricher.Reload(cfg)
+	}
+	if err != nil {
+		st.Error = err.Error()
+		r.status = st
+		r.log.Error("Enrichment config reload failed, keeping the current settings", "trigger", trigger, "err", err)
+		return st
+	}
+
+	st.Success = true
+	st.Reloads++
+	st.LoadedAt = st.At
+	st.RestartRequired = restartRequired(r.startup, cfg)
+	r.status = st
+	r.log.Info("Enrichment config reloaded", "trigger", trigger, "reloads", st.Reloads)
+	if len(st.RestartRequired) > 0 {
+		r.log.Warn("Changed settings take effect after a restart", "sections", strings.Join(st.RestartRequired, ","))
+	}
+	return st
+}
+
+// Watch reloads on SIGHUP and, with a positive interval, when the file at path changes,
+// until ctx is done.
+func (r *Reloader) Watch(ctx context.Context, path string, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	last := fingerprint(path)
+
+	go func() {
+		defer signal.Stop(hup)
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				r.Reload(TriggerSignal)
+			case <-tick:
+				// Polling keeps the watcher dependency-free and also notices files replaced by editors.
+				if cur := fingerprint(path); cur != last {
+					last = cur
+					r.Reload(TriggerFile)
+				}
+			}
+		}
+	}()
+}
+
+// fingerprint identifies the content of the file at path; a missing file has the zero fingerprint.
+// Content rather than the modification time is compared, so quick successive edits are not missed
+// and saving an unchanged file does not reload.
+func fingerprint(path string) [sha256.Size]byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}
+	}
+	return sha256.Sum256(data)
+}
+
+// restartRequired lists the sections other than the reloadable ones that differ between old and new.
+func restartRequired(old, new *config.Config) []string {
+	var changed []string
+	o, n := reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem()
+	for i := 0; i < o.NumField(); i++ {
+		name := o.Type().Field(i).Tag.Get("yaml")
+		if reloadable[name] {
+			continue
+		}
+		if !reflect.DeepEqual(o.Field(i).Interface(), n.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
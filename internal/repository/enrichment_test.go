@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"testing"
+)
+
+func TestMergeEnrichment(t *testing.T) {
+	base := models.User{Name: "Dmitriy", Surname: "Ushakov", Age: 40, Gender: "male", Nationality: "RU", Version: 2}
+	tests := []struct {
+		name    string
+		current func(u *models.User)
+		want    models.User
+	}{
+		{
+			name:    "unchanged",
+			current: func(u *models.User) {},
+			want:    models.User{Name: "Dmitriy", Surname: "Ushakov", Age: 50, Gender: "female", Nationality: "UA", Version: 2},
+		},
+		{
+			name:    "other fields edited",
+			current: func(u *models.User) { u.Surname, u.Version = "Petrov", 3 },
+			want:    models.User{Name: "Dmitriy", Surname: "Petrov", Age: 50, Gender: "female", Nationality: "UA", Version: 3},
+		},
+		{
+			name:    "age edited",
+			current: func(u *models.User) { u.Age, u.Version = 41, 3 },
+			want:    models.User{Name: "Dmitriy", Surname: "Ushakov", Age: 41, Gender: "female", Nationality: "UA", Version: 3},
+		},
+		{
+			name: "every attribute edited",
+			current: func(u *models.User) {
+				u.Age, u.Gender, u.Nationality, u.Version = 41, "female", "KZ", 5
+			},
+			want: models.User{Name: "Dmitriy", Surname: "Ushakov", Age: 41, Gender: "female", Nationality: "KZ", Version: 5},
+		},
+		{
+			name:    "attribute cleared",
+			current: func(u *models.User) { u.Nationality, u.Version = "", 3 },
+			want:    models.User{Name: "Dmitriy", Surname: "Ushakov", Age: 50, Gender: "female", Nationality: "", Version: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := base
+			tt.current(&current)
+			user := base
+			user.Age, user.Gender, user.Nationality = 50, "female", "UA"
+
+			mergeEnrichment(&base, &current, &user)
+			if user != tt.want {
+				t.Errorf("merged = %+v, want %+v", user, tt.want)
+			}
+		})
+	}
+}
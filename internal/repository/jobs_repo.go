@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"TestTask/pkg/logger"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// ErrJobState is returned when a job cannot be retried or cancelled from its current status.
+var ErrJobState = errors.New("job is not in a state that allows this operation")
+
+// JobRepository stores the durable queue of enrichment jobs.
+type JobRepository interface {
+	// CreateWithJob inserts a pending user and its enrichment job in one transaction.
+	// The job remembers the request ID of ctx.
+	CreateWithJob(ctx context.Context, user *models.User, maxAttempts int) (*models.EnrichmentJob, error)
+
+	// Claim marks the next due job as running and returns it, or nil when no job is due.
+	// Concurrent callers in any number of processes never claim the same job.
+	Claim(ctx context.Context) (*models.EnrichmentJob, error)
+	// Complete marks a running job as succeeded.
+	Complete(ctx context.Context, id uint) error
+	// Reschedule puts a failed job back in the queue to run again at nextRunAt.
+	Reschedule(ctx context.Context, id uint, nextRunAt time.Time, lastError string) error
+	// DeadLetter moves a job that exhausted its attempts to the dead state and fails the user's enrichment.
+	DeadLetter(ctx context.Context, job *models.EnrichmentJob, lastError string) error
+	// ReleaseStale requeues running jobs locked before the given time,
+	// e.g. because the process running them crashed.
+	ReleaseStale(ctx context.Context, lockedBefore time.Time) (int64, error)
+
+	// List returns jobs, newest first, optionally filtered by status.
+	List(ctx context.Context, status string, page, limit int) ([]models.EnrichmentJob, error)
+	// Retry requeues a dead or cancelled job with a fresh attempt budget, or returns ErrNotFound or ErrJobState.
+	Retry(ctx context.Context, id uint) (*models.EnrichmentJob, error)
+	// Cancel stops a queued job from running and fails the user's enrichment, or returns ErrNotFound or ErrJobState.
+	Cancel(ctx context.Context, id uint) (*models.EnrichmentJob, error)
+}
+
+// GormJobRepository is the PostgreSQL JobRepository, backed by the enrichment_jobs table.
+type GormJobRepository struct {
+	db *gorm.DB
+}
+
+var _ JobRepository = (*GormJobRepository)(nil)
+
+// NewGormJobRepository returns a JobRepository backed by db.
+func NewGormJobRepository(db *gorm.DB) *GormJobRepository {
+	return &GormJobRepository{db: db}
+}
+
+func (r *GormJobRepository) CreateWithJob(ctx context.Context, user *models.User, maxAttempts int) (*models.EnrichmentJob, error) {
+	var job *models.EnrichmentJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		job = &models.EnrichmentJob{
+			UserID:      user.ID,
+			Status:      models.JobQueued,
+			MaxAttempts: maxAttempts,
+			NextRunAt:   time.Now(),
+			RequestID:   logger.RequestID(ctx),
+		}
+		return tx.Create(job).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Claim locks the job with SELECT ... FOR UPDATE SKIP LOCKED.
+func (r *GormJobRepository) Claim(ctx context.Context) (*models.EnrichmentJob, error) {
+	var job models.EnrichmentJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_run_at <= ?", models.JobQueued, time.Now()).
+			Order("next_run_at, id").
+			Take(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = models.JobRunning
+		job.Attempts++
+		job.LockedAt = &now
+		return tx.Model(&job).Select("Status", "Attempts", "LockedAt").Updates(&job).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *GormJobRepository) Complete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.EnrichmentJob{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": models.JobSucceeded, "locked_at": nil, "last_error": ""}).Error
+}
+
+func (r *GormJobRepository) Reschedule(ctx context.Context, id uint, nextRunAt time.Time, lastError string) error {
+	return r.db.WithContext(ctx).Model(&models.EnrichmentJob{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      models.JobQueued,
+			"next_run_at": nextRunAt,
+			"locked_at":   nil,
+			"last_error":  lastError,
+		}).Error
+}
+
+func (r *GormJobRepository) DeadLetter(ctx context.Context, job *models.EnrichmentJob, lastError string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.EnrichmentJob{}).Where("id = ?", job.ID).
+			Updates(map[string]interface{}{"status": models.JobDead, "locked_at": nil, "last_error": lastError}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", job.UserID).
+			Updates(map[string]interface{}{"enrichment_status": models.EnrichmentFailed, "enrichment_error": lastError, "version": nextVersion}).Error
+	})
+}
+
+func (r *GormJobRepository) ReleaseStale(ctx context.Context, lockedBefore time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&models.EnrichmentJob{}).
+		Where("status = ? AND locked_at < ?", models.JobRunning, lockedBefore).
+		Updates(map[string]interface{}{"status": models.JobQueued, "locked_at": nil, "next_run_at": time.Now()})
+	return res.RowsAffected, res.Error
+}
+
+func (r *GormJobRepository) List(ctx context.Context, status string, page, limit int) ([]models.EnrichmentJob, error) {
+	var jobs []models.EnrichmentJob
+	query := r.db.WithContext(ctx).Model(&models.EnrichmentJob{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	res := query.Order("id DESC").Limit(limit).Offset((page - 1) * limit).Find(&jobs)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return jobs, nil
+}
+
+func (r *GormJobRepository) Retry(ctx context.Context, id uint) (*models.EnrichmentJob, error) {
+	return r.updateState(ctx, id, []string{models.JobDead, models.JobCancelled}, func(tx *gorm.DB, job *models.EnrichmentJob) error {
+		job.Status = models.JobQueued
+		job.Attempts = 0
+		job.NextRunAt = time.Now()
+		job.LastError = ""
+		err := tx.Model(job).Select("Status", "Attempts", "NextRunAt", "LastError").Updates(job).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", job.UserID).
+			Updates(map[string]interface{}{"enrichment_status": models.EnrichmentPending, "enrichment_error": "", "version": nextVersion}).Error
+	})
+}
+
+func (r *GormJobRepository) Cancel(ctx context.Context, id uint) (*models.EnrichmentJob, error) {
+	return r.updateState(ctx, id, []string{models.JobQueued}, func(tx *gorm.DB, job *models.EnrichmentJob) error {
+		job.Status = models.JobCancelled
+		job.LastError = "cancelled"
+		err := tx.Model(job).Select("Status", "LastError").Updates(job).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", job.UserID).
+			Updates(map[string]interface{}{"enrichment_status": models.EnrichmentFailed, "enrichment_error": "enrichment job cancelled", "version": nextVersion}).Error
+	})
+}
+
+func (r *GormJobRepository) updateState(ctx context.Context, id uint, from []string, update func(tx *gorm.DB, job *models.EnrichmentJob) error) (*models.EnrichmentJob, error) {
+	var job models.EnrichmentJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, id).Error
+		if err != nil {
+			return err
+		}
+		allowed := false
+		for _, status := range from {
+			if job.Status == status {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return ErrJobState
+		}
+		return update(tx, &job)
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &job, nil
+}
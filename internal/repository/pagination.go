@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor that is malformed or was issued for a different sort.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Sort orders users by one column.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// PageRequest selects one page of a user list.
+type PageRequest struct {
+	Sorts []Sort
+	Limit int
+	// Cursor continues after the last user of a previous page; Offset is used without it.
+	Cursor string
+	Offset int
+	// Count requests the total number of matching users.
+	Count bool
+}
+
+// PageInfo describes where a page is in the full result.
+type PageInfo struct {
+	NextCursor string
+	HasMore    bool
+	Total      *int64
+}
+
+// sortColumn is a sortable column with its value on a user and the type it is decoded
+// into from a cursor.
+type sortColumn struct {
+	value func(u *models.User) interface{}
+	zero  func() interface{}
+}
+
+// sortColumns is the whitelist of sortable fields, named after their columns.
+var sortColumns = map[string]sortColumn{
+	"id":          {func(u *models.User) interface{} { return u.ID }, func() interface{} { return new(uint) }},
+	"name":        {func(u *models.User) interface{} { return u.Name }, func() interface{} { return new(string) }},
+	"surname":     {func(u *models.User) interface{} { return u.Surname }, func() interface{} { return new(string) }},
+	"age":         {func(u *models.User) interface{} { return u.Age }, func() interface{} { return new(int) }},
+	"gender":      {func(u *models.User) interface{} { return u.Gender }, func() interface{} { return new(string) }},
+	"nationality": {func(u *models.User) interface{} { return u.Nationality }, func() interface{} { return new(string) }},
+	"created_at":  {func(u *models.User) interface{} { return u.CreatedAt }, func() interface{} { return new(time.Time) }},
+	"updated_at":  {func(u *models.User) interface{} { return u.UpdatedAt }, func() interface{} { return new(time.Time) }},
+}
+
+// ParseSort parses a comma-separated list of sortable fields, each optionally prefixed
+// with "-" for descending order, e.g. "-age,surname".
+func ParseSort(s string) ([]Sort, error) {
+	var sorts []Sort
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		desc := strings.HasPrefix(field, "-")
+		column := strings.TrimPrefix(field, "-")
+		if _, ok := sortColumns[column]; !ok {
+			return nil, fmt.Errorf("cannot sort by %q", column)
+		}
+		sorts = append(sorts, Sort{Column: column, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// keysetOf makes sorts a total order by appending the id unless it is already sorted on.
+func keysetOf(sorts []Sort) []Sort {
+	for _, s := range sorts {
+		if s.Column == "id" {
+			return sorts
+		}
+	}
+	return append(sorts[:len(sorts):len(sorts)], Sort{Column: "id"})
+}
+
+func sortSignature(keys []Sort) string {
+	parts := make([]string, len(keys))
+	for i, s := range keys {
+		parts[i] = s.Column
+		if s.Desc {
+			parts[i] = "-" + s.Column
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// cursor is the decoded form of an opaque page cursor: the sort it was issued for
+// and the sort key of the last user on the page.
+type cursor struct {
+	Sort  string            `json:"s"`
+	After []json.RawMessage `json:"a"`
+}
+
+func encodeCursor(keys []Sort, last *models.User) string {
+	c := cursor{Sort: sortSignature(keys)}
+	for _, s := range keys {
+		raw, _ := json.Marshal(sortColumns[s.Column].value(last))
+		c.After = append(c.After, raw)
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string, keys []Sort) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sortSignature(keys) || len(c.After) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+
+	after := make([]interface{}, len(keys))
+	for i, s := range keys {
+		v := sortColumns[s.Column].zero()
+		if err := json.Unmarshal(c.After[i], v); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		after[i] = v
+	}
+	return after, nil
+}
+
+// keysetCondition matches the rows that come after the given sort key:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys.
+func keysetCondition(keys []Sort, after []interface{}) (string, []interface{}) {
+	var or []string
+	var vars []interface{}
+	for i, s := range keys {
+		var and []string
+		for j := 0; j < i; j++ {
+			and = append(and, keys[j].Column+" = ?")
+			vars = append(vars, after[j])
+		}
+		op := " > ?"
+		if s.Desc {
+			op = " < ?"
+		}
+		and = append(and, s.Column+op)
+		vars = append(vars, after[i])
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	return "(" + strings.Join(or, " OR ") + ")", vars
+}
+
+func sortValues(keys []Sort, u *models.User) []interface{} {
+	values := make([]interface{}, len(keys))
+	for i, s := range keys {
+		values[i] = sortColumns[s.Column].value(u)
+	}
+	return values
+}
+
+// compareKeys compares the sort key of u with after, a sort key in the order of keys,
+// like keysetCondition does in SQL.
+func compareKeys(keys []Sort, u *models.User, after []interface{}) int {
+	for i, s := range keys {
+		c := compareValues(sortColumns[s.Column].value(u), after[i])
+		if s.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues compares two values of a sort column; b may be a pointer decoded from a cursor.
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case uint:
+		return cmp.Compare(a, indirect[uint](b))
+	case int:
+		return cmp.Compare(a, indirect[int](b))
+	case string:
+		return cmp.Compare(a, indirect[string](b))
+	case time.Time:
+		return a.Compare(indirect[time.Time](b))
+	}
+	panic(fmt.Sprintf("unsupported sort value %T", a))
+}
+
+func indirect[T any](v interface{}) T {
+	if p, ok := v.(*T); ok {
+		return *p
+	}
+	return v.(T)
+}
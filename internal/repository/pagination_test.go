@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []Sort
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"age", []Sort{{Column: "age"}}, false},
+		{"-age, surname", []Sort{{Column: "age", Desc: true}, {Column: "surname"}}, false},
+		{"name,,", []Sort{{Column: "name"}}, false},
+		{"password", nil, true},
+		{"age;drop table users", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSort(tt.in)
+			if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSort(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeysetOf(t *testing.T) {
+	sorts := make([]Sort, 1, 4)
+	sorts[0] = Sort{Column: "age", Desc: true}
+	keys := keysetOf(sorts)
+	if want := []Sort{{Column: "age", Desc: true}, {Column: "id"}}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("keysetOf = %v, want %v", keys, want)
+	}
+	if len(sorts) != 1 || sorts[:2][1] != (Sort{}) {
+		t.Fatalf("keysetOf modified the caller's slice: %v", sorts[:2])
+	}
+
+	withID := []Sort{{Column: "id", Desc: true}, {Column: "name"}}
+	if keys := keysetOf(withID); !reflect.DeepEqual(keys, withID) {
+		t.Errorf("keysetOf = %v, want the sorts unchanged when they include id", keys)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2025, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	user := &models.User{ID: 42, Name: "Dmitriy", Surname: "Ushakov", Age: 40, Gender: "male", Nationality: "RU"}
+	user.CreatedAt, user.UpdatedAt = created, created.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		keys []Sort
+		want []interface{}
+	}{
+		{"id", []Sort{{Column: "id"}}, []interface{}{uint(42)}},
+		{"age desc then id", []Sort{{Column: "age", Desc: true}, {Column: "id"}}, []interface{}{40, uint(42)}},
+		{"strings", []Sort{{Column: "surname"}, {Column: "name"}, {Column: "id"}}, []interface{}{"Ushakov", "Dmitriy", uint(42)}},
+		{"time", []Sort{{Column: "created_at", Desc: true}, {Column: "id"}}, []interface{}{created, uint(42)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after, err := decodeCursor(encodeCursor(tt.keys, user), tt.keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range after {
+				if got := reflect.ValueOf(v).Elem().Interface(); got != tt.want[i] {
+					t.Errorf("key %d = %#v, want %#v", i, got, tt.want[i])
+				}
+			}
+			if c := compareKeys(tt.keys, user, after); c != 0 {
+				t.Errorf("compareKeys(user, its own cursor) = %d, want 0", c)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	user := &models.User{ID: 7, Age: 30}
+	byAge := []Sort{{Column: "age"}, {Column: "id"}}
+	valid := encodeCursor(byAge, user)
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+		keys   []Sort
+	}{
+		{"not base64", "!!!", byAge},
+		{"not json", encode("age"), byAge},
+		{"other sort", valid, []Sort{{Column: "age", Desc: true}, {Column: "id"}}},
+		{"missing key", encode(`{"s":"age,id","a":[30]}`), byAge},
+		{"wrong type", encode(`{"s":"age,id","a":["thirty",7]}`), byAge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.cursor, tt.keys); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []Sort
+		after    []interface{}
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{"id", []Sort{{Column: "id"}}, []interface{}{5},
+			"((id > ?))", []interface{}{5}},
+		{"descending", []Sort{{Column: "age", Desc: true}, {Column: "id"}}, []interface{}{30, 5},
+			"((age < ?) OR (age = ? AND id > ?))", []interface{}{30, 30, 5}},
+		{"three keys", []Sort{{Column: "surname"}, {Column: "name", Desc: true}, {Column: "id"}}, []interface{}{"U", "D", 5},
+			"((surname > ?) OR (surname = ? AND name < ?) OR (surname = ? AND name = ? AND id > ?))",
+			[]interface{}{"U", "U", "D", "U", "D", 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars := keysetCondition(tt.keys, tt.after)
+			if sql != tt.wantSQL || !reflect.DeepEqual(vars, tt.wantVars) {
+				t.Errorf("keysetCondition = %q %v, want %q %v", sql, vars, tt.wantSQL, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestCompareKeys(t *testing.T) {
+	keys := []Sort{{Column: "age", Desc: true}, {Column: "id"}}
+	last := &models.User{ID: 5, Age: 30}
+	after, err := decodeCursor(encodeCursor(keys, last), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		user models.User
+		want int
+	}{
+		{"younger comes after", models.User{ID: 1, Age: 20}, 1},
+		{"older comes before", models.User{ID: 9, Age: 40}, -1},
+		{"same age, higher id comes after", models.User{ID: 6, Age: 30}, 1},
+		{"same age, lower id comes before", models.User{ID: 4, Age: 30}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compareKeys(keys, &tt.user, after); got != tt.want {
+				t.Errorf("compareKeys = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
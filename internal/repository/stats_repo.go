@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+)
+
+// UserStats are aggregates over the users matching a filter. Age 0 means the age is unknown
+// and is left out of the age buckets and averages.
+type UserStats struct {
+	Total         int64
+	ByGender      []GenderCount
+	ByNationality []NationalityStats
+	AgeBuckets    []AgeBucket
+	Coverage      Coverage
+}
+
+type GenderCount struct {
+	Gender string
+	Count  int64
+}
+
+type NationalityStats struct {
+	Nationality string
+	Count       int64
+	AverageAge  *float64
+	MedianAge   *float64
+}
+
+// AgeBucket counts the users aged From to To, inclusive.
+type AgeBucket struct {
+	From  int `gorm:"column:bucket_from"`
+	To    int `gorm:"-"`
+	Count int64
+}
+
+// Coverage tells how many users are enriched and which attributes are known.
+type Coverage struct {
+	Succeeded       int64
+	Pending         int64
+	Failed          int64
+	WithAge         int64
+	WithGender      int64
+	WithNationality int64
+	WithMetadata    int64
+}
+
+// Stats computes UserStats in the database. The queries run in one read-only snapshot,
+// so the numbers agree with each other.
+func (r *GormUserRepository) Stats(ctx context.Context, filter UserFilter, bucketWidth int) (*UserStats, error) {
+	var stats UserStats
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		users := func() *gorm.DB { return applyFilter(tx.Model(&models.User{}), filter) }
+
+		if err := users().Count(&stats.Total).Error; err != nil {
+			return err
+		}
+		err := users().Select("gender, count(*) AS count").
+			Group("gender").Order("count DESC, gender").Scan(&stats.ByGender).Error
+		if err != nil {
+			return err
+		}
+		err = users().Select("nationality, count(*) AS count, " +
+			"avg(age) FILTER (WHERE age > 0) AS average_age, " +
+			"percentile_cont(0.5) WITHIN GROUP (ORDER BY age) FILTER (WHERE age > 0) AS median_age").
+			Group("nationality").Order("count DESC, nationality").Scan(&stats.ByNationality).Error
+		if err != nil {
+			return err
+		}
+		err = users().Select("age / ? * ? AS bucket_from, count(*) AS count", bucketWidth, bucketWidth).
+			Where("age > 0").Group("bucket_from").Order("bucket_from").Scan(&stats.AgeBuckets).Error
+		if err != nil {
+			return err
+		}
+		return users().Select("count(*) FILTER (WHERE enrichment_status = ?) AS succeeded, "+
+			"count(*) FILTER (WHERE enrichment_status = ?) AS pending, "+
+			"count(*) FILTER (WHERE enrichment_status = ?) AS failed, "+
+			"count(*) FILTER (WHERE age > 0) AS with_age, "+
+			"count(*) FILTER (WHERE gender <> '') AS with_gender, "+
+			"count(*) FILTER (WHERE nationality <> '') AS with_nationality, "+
+			"count(*) FILTER (WHERE EXISTS (SELECT 1 FROM user_enrichments e WHERE e.user_id = users.id)) AS with_metadata",
+			models.EnrichmentSucceeded, models.EnrichmentPending, models.EnrichmentFailed).
+			Scan(&stats.Coverage).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stats.AgeBuckets {
+		stats.AgeBuckets[i].To = stats.AgeBuckets[i].From + bucketWidth - 1
+	}
+	return &stats, nil
+}
@@ -0,0 +1,403 @@
+package repository
+
+import (
+	"TestTask/internal/models"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository is a UserRepository kept in memory, for tests and for running
+// without a database. Search is approximated by a case-insensitive substring match
+// and strings are sorted bytewise rather than by the database collation.
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[uint]*models.User
+	nextID uint
+}
+
+var _ UserRepository = (*MemoryUserRepository)(nil)
+
+// NewMemoryUserRepository returns an empty MemoryUserRepository.
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[uint]*models.User)}
+}
+
+func (r *MemoryUserRepository) GetByID(_ context.Context, id uint) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	return cloneUser(u), nil
+}
+
+func (r *MemoryUserRepository) GetByIDUnscoped(_ context.Context, id uint) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneUser(u), nil
+}
+
+func (r *MemoryUserRepository) Create(_ context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.create(user)
+	return nil
+}
+
+func (r *MemoryUserRepository) CreateBatch(_ context.Context, users []*models.User, _ int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range users {
+		r.create(user)
+	}
+	return nil
+}
+
+// create assigns the generated fields of user like the database defaults do and stores a copy.
+func (r *MemoryUserRepository) create(user *models.User) {
+	now := time.Now()
+	r.nextID++
+	user.ID = r.nextID
+	user.CreatedAt, user.UpdatedAt = now, now
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	if user.EnrichmentStatus == "" {
+		user.EnrichmentStatus = models.EnrichmentSucceeded
+	}
+	if user.Enrichment != nil {
+		user.Enrichment.ID = user.ID
+		user.Enrichment.UserID = user.ID
+		user.Enrichment.CreatedAt, user.Enrichment.UpdatedAt = now, now
+	}
+	r.users[user.ID] = cloneUser(user)
+}
+
+func (r *MemoryUserRepository) UpdateFields(_ context.Context, id, version uint, fields map[string]interface{}) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u := r.active(id, version)
+	if u == nil {
+		return false, nil
+	}
+
+	updated := *u
+	for column, value := range fields {
+		var ok bool
+		switch column {
+		case "name":
+			updated.Name, ok = value.(string)
+		case "surname":
+			updated.Surname, ok = value.(string)
+		case "age":
+			updated.Age, ok = value.(int)
+		case "gender":
+			updated.Gender, ok = value.(string)
+		case "nationality":
+			updated.Nationality, ok = value.(string)
+		case "enrichment_status":
+			updated.EnrichmentStatus, ok = value.(string)
+		case "enrichment_error":
+			updated.EnrichmentError, ok = value.(string)
+		}
+		if !ok {
+			return false, fmt.Errorf("cannot set %s to %v", column, value)
+		}
+	}
+	*u = updated
+	touch(u)
+	return true, nil
+}
+
+func (r *MemoryUserRepository) SaveEnrichment(_ context.Context, base, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u := r.active(user.ID, 0)
+	if u == nil {
+		return ErrNotFound
+	}
+	mergeEnrichment(base, u, user)
+
+	u.Age, u.Gender, u.Nationality = user.Age, user.Gender, user.Nationality
+	u.EnrichmentStatus, u.EnrichmentError = user.EnrichmentStatus, user.EnrichmentError
+	touch(u)
+	user.Version = u.Version
+	if user.Enrichment == nil {
+		return nil
+	}
+
+	e := *user.Enrichment
+	e.ID, e.UserID = u.ID, u.ID
+	e.UpdatedAt = u.UpdatedAt
+	e.CreatedAt = u.UpdatedAt
+	if u.Enrichment != nil {
+		e.CreatedAt = u.Enrichment.CreatedAt
+	}
+	user.Enrichment.ID, user.Enrichment.UserID = e.ID, e.UserID
+	user.Enrichment.CreatedAt, user.Enrichment.UpdatedAt = e.CreatedAt, e.UpdatedAt
+	e.NationalityCandidates = slices.Clone(e.NationalityCandidates)
+	u.Enrichment = &e
+	return nil
+}
+
+func (r *MemoryUserRepository) SetEnrichmentStatus(_ context.Context, id uint, status, errMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u := r.active(id, 0); u != nil {
+		u.EnrichmentStatus, u.EnrichmentError = status, errMsg
+		touch(u)
+	}
+	return nil
+}
+
+func (r *MemoryUserRepository) Delete(_ context.Context, id, version uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u := r.active(id, version)
+	if u == nil {
+		return false, nil
+	}
+	touch(u)
+	u.DeletedAt.Time, u.DeletedAt.Valid = u.UpdatedAt, true
+	return true, nil
+}
+
+func (r *MemoryUserRepository) Restore(_ context.Context, id uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || !u.DeletedAt.Valid {
+		return false, nil
+	}
+	u.DeletedAt.Valid = false
+	touch(u)
+	return true, nil
+}
+
+func (r *MemoryUserRepository) Purge(_ context.Context, id, version uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || (version > 0 && u.Version != version) {
+		return false, nil
+	}
+	delete(r.users, id)
+	return true, nil
+}
+
+func (r *MemoryUserRepository) PurgeDeleted(_ context.Context, before time.Time, limit int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uint
+	for id, u := range r.users {
+		if u.DeletedAt.Valid && u.DeletedAt.Time.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	for _, id := range ids {
+		delete(r.users, id)
+	}
+	return int64(len(ids)), nil
+}
+
+func (r *MemoryUserRepository) List(_ context.Context, filter UserFilter, req PageRequest) ([]models.User, PageInfo, error) {
+	var info PageInfo
+	users := r.match(filter)
+	if req.Count {
+		total := int64(len(users))
+		info.Total = &total
+	}
+
+	ranked := len(req.Sorts) == 0 && filter.Search != ""
+	keys := keysetOf(req.Sorts)
+	if !ranked {
+		sort.SliceStable(users, func(i, j int) bool { return compareKeys(keys, &users[i], sortValues(keys, &users[j])) < 0 })
+	}
+
+	switch {
+	case req.Cursor != "" && ranked:
+		return nil, info, ErrInvalidCursor
+	case req.Cursor != "":
+		after, err := decodeCursor(req.Cursor, keys)
+		if err != nil {
+			return nil, info, err
+		}
+		start := sort.Search(len(users), func(i int) bool { return compareKeys(keys, &users[i], after) > 0 })
+		users = users[start:]
+	default:
+		users = users[min(req.Offset, len(users)):]
+	}
+
+	if len(users) > req.Limit {
+		users = users[:req.Limit]
+		info.HasMore = true
+		if !ranked {
+			info.NextCursor = encodeCursor(keys, &users[len(users)-1])
+		}
+	}
+	return users, info, nil
+}
+
+func (r *MemoryUserRepository) ListAfter(_ context.Context, filter UserFilter, afterID uint, limit int) ([]models.User, error) {
+	users := r.match(filter)
+	start := sort.Search(len(users), func(i int) bool { return users[i].ID > afterID })
+	users = users[start:]
+	return users[:min(limit, len(users))], nil
+}
+
+func (r *MemoryUserRepository) Stream(ctx context.Context, filter UserFilter, fn func(user *models.User) error) error {
+	for _, user := range r.match(filter) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryUserRepository) Stats(_ context.Context, filter UserFilter, bucketWidth int) (*UserStats, error) {
+	users := r.match(filter)
+	stats := UserStats{Total: int64(len(users))}
+
+	genders := make(map[string]int64)
+	nationalities := make(map[string][]int)
+	buckets := make(map[int]int64)
+	for _, u := range users {
+		genders[u.Gender]++
+		nationalities[u.Nationality] = append(nationalities[u.Nationality], u.Age)
+		if u.Age > 0 {
+			buckets[u.Age/bucketWidth*bucketWidth]++
+			stats.Coverage.WithAge++
+		}
+		switch u.EnrichmentStatus {
+		case models.EnrichmentSucceeded:
+			stats.Coverage.Succeeded++
+		case models.EnrichmentPending:
+			stats.Coverage.Pending++
+		case models.EnrichmentFailed:
+			stats.Coverage.Failed++
+		}
+		if u.Gender != "" {
+			stats.Coverage.WithGender++
+		}
+		if u.Nationality != "" {
+			stats.Coverage.WithNationality++
+		}
+		if u.Enrichment != nil {
+			stats.Coverage.WithMetadata++
+		}
+	}
+
+	for gender, count := range genders {
+		stats.ByGender = append(stats.ByGender, GenderCount{Gender: gender, Count: count})
+	}
+	slices.SortFunc(stats.ByGender, func(a, b GenderCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Gender, b.Gender))
+	})
+
+	for nationality, ages := range nationalities {
+		n := NationalityStats{Nationality: nationality, Count: int64(len(ages))}
+		known := slices.DeleteFunc(ages, func(age int) bool { return age <= 0 })
+		if len(known) > 0 {
+			slices.Sort(known)
+			sum := 0
+			for _, age := range known {
+				sum += age
+			}
+			avg := float64(sum) / float64(len(known))
+			median := float64(known[(len(known)-1)/2]+known[len(known)/2]) / 2
+			n.AverageAge, n.MedianAge = &avg, &median
+		}
+		stats.ByNationality = append(stats.ByNationality, n)
+	}
+	slices.SortFunc(stats.ByNationality, func(a, b NationalityStats) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Nationality, b.Nationality))
+	})
+
+	for from, count := range buckets {
+		stats.AgeBuckets = append(stats.AgeBuckets, AgeBucket{From: from, To: from + bucketWidth - 1, Count: count})
+	}
+	slices.SortFunc(stats.AgeBuckets, func(a, b AgeBucket) int { return cmp.Compare(a.From, b.From) })
+	return &stats, nil
+}
+
+// active returns the stored user if it is not deleted and, for a non-zero version, still has it.
+func (r *MemoryUserRepository) active(id, version uint) *models.User {
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt.Valid || (version > 0 && u.Version != version) {
+		return nil
+	}
+	return u
+}
+
+// match returns copies of the users matching filter, in id order.
+func (r *MemoryUserRepository) match(filter UserFilter) []models.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var users []models.User
+	for _, u := range r.users {
+		if matches(u, filter) {
+			users = append(users, *cloneUser(u))
+		}
+	}
+	slices.SortFunc(users, func(a, b models.User) int { return cmp.Compare(a.ID, b.ID) })
+	return users
+}
+
+func matches(u *models.User, f UserFilter) bool {
+	e := u.Enrichment
+	search := strings.ToLower(f.Search)
+	switch {
+	case u.DeletedAt.Valid && !f.IncludeDeleted,
+		f.Gender != "" && u.Gender != f.Gender,
+		len(f.Nationalities) > 0 && !slices.Contains(f.Nationalities, u.Nationality),
+		f.Name != "" && !strings.HasPrefix(strings.ToLower(u.Name), strings.ToLower(f.Name)),
+		f.Surname != "" && !strings.HasPrefix(strings.ToLower(u.Surname), strings.ToLower(f.Surname)),
+		search != "" && !strings.Contains(strings.ToLower(u.Name+" "+u.Surname), search),
+		f.CreatedAfter != nil && u.CreatedAt.Before(*f.CreatedAfter),
+		f.CreatedBefore != nil && !u.CreatedAt.Before(*f.CreatedBefore),
+		f.UpdatedAfter != nil && u.UpdatedAt.Before(*f.UpdatedAfter),
+		f.UpdatedBefore != nil && !u.UpdatedAt.Before(*f.UpdatedBefore),
+		f.NationalityMissing && u.Nationality != "",
+		f.AgeMin != nil && u.Age < *f.AgeMin,
+		f.AgeMax != nil && u.Age > *f.AgeMax,
+		f.EnrichmentStatus != "" && u.EnrichmentStatus != f.EnrichmentStatus,
+		f.GenderProbabilityMin != nil && (e == nil || e.GenderProbability < *f.GenderProbabilityMin),
+		f.NationalityProbabilityMin != nil && (e == nil || e.NationalityProbability < *f.NationalityProbabilityMin),
+		f.EnrichedBefore != nil && e != nil && !e.EnrichedAt.Before(*f.EnrichedBefore):
+		return false
+	}
+	return true
+}
+
+func touch(u *models.User) {
+	u.UpdatedAt = time.Now()
+	u.Version++
+}
+
+func cloneUser(u *models.User) *models.User {
+	c := *u
+	if u.Enrichment != nil {
+		e := *u.Enrichment
+		e.NationalityCandidates = slices.Clone(e.NationalityCandidates)
+		c.Enrichment = &e
+	}
+	return &c
+}
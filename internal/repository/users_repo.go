@@ -1,49 +1,398 @@
 package repository
 
 import (
-	"TestTask/internal/database"
 	"TestTask/internal/models"
+	"context"
+	"database/sql"
+	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
 )
 
+// ErrNotFound is returned when no user has the requested id.
+var ErrNotFound = errors.New("record not found")
+
 type UserFilter struct {
-	Gender      string
-	Nationality string
-	AgeMin      *int
-	AgeMax      *int
+	Gender        string
+	Nationalities []string
+	AgeMin        *int
+	AgeMax        *int
+
+	// Name and Surname match case-insensitive prefixes; Search matches either fuzzily using pg_trgm.
+	Name    string
+	Surname string
+	Search  string
+
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+
+	GenderProbabilityMin      *float64
+	NationalityProbabilityMin *float64
+
+	NationalityMissing bool
+	EnrichmentStatus   string
+	EnrichedBefore     *time.Time
+
+	// IncludeDeleted also matches soft-deleted users.
+	IncludeDeleted bool
+}
+
+// UserRepository stores users and their enrichment metadata.
+//
+// Every change of a user increments its Version. Methods taking a version apply only if the
+// user still has it, or unconditionally for version 0; they report whether a user was affected.
+// Soft-deleted users are only visible to the Unscoped methods and to filters with IncludeDeleted.
+type UserRepository interface {
+	// GetByID returns the user with its enrichment metadata, or ErrNotFound.
+	GetByID(ctx context.Context, id uint) (*models.User, error)
+	// GetByIDUnscoped returns the user even if it is soft-deleted, or ErrNotFound.
+	GetByIDUnscoped(ctx context.Context, id uint) (*models.User, error)
+
+	Create(ctx context.Context, user *models.User) error
+	// CreateBatch inserts users, with their enrichment metadata, batchSize rows per statement.
+	CreateBatch(ctx context.Context, users []*models.User, batchSize int) error
+
+	// UpdateFields sets only the given columns of a user.
+	UpdateFields(ctx context.Context, id, version uint, fields map[string]interface{}) (bool, error)
+	// SaveEnrichment stores the enriched attributes and status of user together with its
+	// enrichment metadata, replacing the metadata of a previous enrichment. base is the user
+	// as read before the lookup: attributes a client changed since then are kept, and user is
+	// updated to the stored values and version. It returns ErrNotFound if the user is gone.
+	SaveEnrichment(ctx context.Context, base, user *models.User) error
+	// SetEnrichmentStatus records the outcome of an enrichment that did not change the user's attributes.
+	SetEnrichmentStatus(ctx context.Context, id uint, status, errMsg string) error
+
+	// Delete soft-deletes a user and Restore undoes it.
+	Delete(ctx context.Context, id, version uint) (bool, error)
+	Restore(ctx context.Context, id uint) (bool, error)
+	// Purge permanently erases a user, soft-deleted or not, together with its enrichment metadata and jobs.
+	Purge(ctx context.Context, id, version uint) (bool, error)
+	// PurgeDeleted permanently erases up to limit users soft-deleted before the given time.
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error)
+
+	// List returns one page of users matching filter. Pages are addressed by the cursor of the
+	// previous page, or by offset for the first page and for results ranked by relevance to
+	// filter.Search, which is the order used when no sort is given.
+	List(ctx context.Context, filter UserFilter, page PageRequest) ([]models.User, PageInfo, error)
+	// ListAfter returns up to limit users matching filter with an id greater than afterID, ordered by id.
+	// Unlike page offsets it stays correct while the matching set changes, e.g. during a backfill.
+	ListAfter(ctx context.Context, filter UserFilter, afterID uint, limit int) ([]models.User, error)
+	// Stream calls fn for every user matching filter, with its enrichment metadata, in id order,
+	// without loading them all into memory. The users are read from one consistent snapshot.
+	Stream(ctx context.Context, filter UserFilter, fn func(user *models.User) error) error
+	// Stats aggregates the users matching filter, grouping ages into buckets of bucketWidth years.
+	Stats(ctx context.Context, filter UserFilter, bucketWidth int) (*UserStats, error)
+}
+
+// GormUserRepository is the PostgreSQL UserRepository.
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+var _ UserRepository = (*GormUserRepository)(nil)
+
+// NewGormUserRepository returns a UserRepository backed by db.
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+func (r *GormUserRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Preload("Enrichment").First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) GetByIDUnscoped(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Unscoped().Preload("Enrichment").First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *GormUserRepository) CreateBatch(ctx context.Context, users []*models.User, batchSize int) error {
+	return r.db.WithContext(ctx).CreateInBatches(users, batchSize).Error
+}
+
+// nextVersion is assigned to the version column by every update of a user.
+var nextVersion = gorm.Expr("version + 1")
+
+func (r *GormUserRepository) UpdateFields(ctx context.Context, id, version uint, fields map[string]interface{}) (bool, error) {
+	updates := make(map[string]interface{}, len(fields)+1)
+	for column, value := range fields {
+		updates[column] = value
+	}
+	updates["version"] = nextVersion
+
+	res := withVersion(r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id), version).Updates(updates)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *GormUserRepository) SaveEnrichment(ctx context.Context, base, user *models.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the row so no edit slips in between the merge and the write.
+		var current models.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, user.ID).Error
+		if err != nil {
+			return notFound(err)
+		}
+		mergeEnrichment(base, &current, user)
+
+		res := withVersion(tx.Model(&models.User{}).Where("id = ?", user.ID), current.Version).Updates(map[string]interface{}{
+			"age":               user.Age,
+			"gender":            user.Gender,
+			"nationality":       user.Nationality,
+			"enrichment_status": user.EnrichmentStatus,
+			"enrichment_error":  user.EnrichmentError,
+			"version":           nextVersion,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		user.Version++
+		if user.Enrichment == nil {
+			return nil
+		}
+		user.Enrichment.UserID = user.ID
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns(enrichmentColumns),
+		}).Create(user.Enrichment).Error
+	})
+}
+
+// mergeEnrichment prepares user, carrying enriched attributes looked up for base, to replace
+// current. An attribute a client changed since base keeps the client's value, as do the
+// fields enrichment does not touch; user takes the version of current.
+func mergeEnrichment(base, current, user *models.User) {
+	if current.Age != base.Age {
+		user.Age = current.Age
+	}
+	if current.Gender != base.Gender {
+		user.Gender = current.Gender
+	}
+	if current.Nationality != base.Nationality {
+		user.Nationality = current.Nationality
+	}
+	user.Name, user.Surname = current.Name, current.Surname
+	user.Version = current.Version
+}
+
+var enrichmentColumns = []string{
+	"updated_at", "age_provider", "age_count",
+	"gender_provider", "gender_probability", "gender_count",
+	"nationality_provider", "nationality_probability", "nationality_count",
+	"nationality_candidates", "enriched_at",
+}
+
+func (r *GormUserRepository) SetEnrichmentStatus(ctx context.Context, id uint, status, errMsg string) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"enrichment_status": status, "enrichment_error": errMsg, "version": nextVersion}).Error
+}
+
+func (r *GormUserRepository) Delete(ctx context.Context, id, version uint) (bool, error) {
+	res := withVersion(r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id), version).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "version": nextVersion})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *GormUserRepository) Restore(ctx context.Context, id uint) (bool, error) {
+	res := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": nextVersion})
+	return res.RowsAffected > 0, res.Error
 }
 
-func GetById(user *models.User, id int) *gorm.DB {
-	res := database.DB.First(user, id)
-	return res
+func (r *GormUserRepository) Purge(ctx context.Context, id, version uint) (bool, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		purged, err = purge(tx, withVersion(tx.Unscoped().Model(&models.User{}).Where("id = ?", id), version))
+		return err
+	})
+	return purged > 0, err
 }
 
-func SaveInDb(user *models.User) *gorm.DB {
-	res := database.DB.Save(user)
-	return res
+func (r *GormUserRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		purged, err = purge(tx, tx.Unscoped().Model(&models.User{}).Where("deleted_at < ?", before).Order("id").Limit(limit))
+		return err
+	})
+	return purged, err
 }
 
-func DeleteInDb(user *models.User, id int) *gorm.DB {
-	res := database.DB.Delete(user, id)
-	return res
+// purge hard-deletes the users selected by users and the rows that refer to them.
+func purge(tx *gorm.DB, users *gorm.DB) (int64, error) {
+	var ids []uint
+	if err := users.Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := tx.Where("user_id IN ?", ids).Delete(&models.EnrichmentJob{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("user_id IN ?", ids).Delete(&models.UserEnrichment{}).Error; err != nil {
+		return 0, err
+	}
+	res := tx.Unscoped().Where("id IN ?", ids).Delete(&models.User{})
+	return res.RowsAffected, res.Error
+}
+
+func withVersion(query *gorm.DB, version uint) *gorm.DB {
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+	return query
 }
 
-func CreateInDb(user *models.User) *gorm.DB {
-	res := database.DB.Create(&user)
-	return res
+func (r *GormUserRepository) List(ctx context.Context, filter UserFilter, req PageRequest) ([]models.User, PageInfo, error) {
+	var users []models.User
+	var info PageInfo
+	db := r.db.WithContext(ctx)
+
+	query := applyFilter(db.Model(&models.User{}).Preload("Enrichment"), filter)
+	if req.Count {
+		var total int64
+		if err := applyFilter(db.Model(&models.User{}), filter).Count(&total).Error; err != nil {
+			return nil, info, err
+		}
+		info.Total = &total
+	}
+
+	ranked := len(req.Sorts) == 0 && filter.Search != ""
+	keys := keysetOf(req.Sorts)
+	if ranked {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "similarity(name || ' ' || surname, ?) DESC",
+			Vars: []interface{}{filter.Search},
+		}}).Order("id")
+	} else {
+		for _, s := range keys {
+			query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: s.Column}, Desc: s.Desc})
+		}
+	}
+
+	switch {
+	case req.Cursor != "" && ranked:
+		return nil, info, ErrInvalidCursor
+	case req.Cursor != "":
+		after, err := decodeCursor(req.Cursor, keys)
+		if err != nil {
+			return nil, info, err
+		}
+		sql, vars := keysetCondition(keys, after)
+		query = query.Where(sql, vars...)
+	default:
+		query = query.Offset(req.Offset)
+	}
+
+	// One extra row tells whether there is a next page.
+	res := query.Limit(req.Limit + 1).Find(&users)
+	if res.Error != nil {
+		return nil, info, res.Error
+	}
+	if len(users) > req.Limit {
+		users = users[:req.Limit]
+		info.HasMore = true
+		if !ranked {
+			info.NextCursor = encodeCursor(keys, &users[len(users)-1])
+		}
+	}
+
+	return users, info, nil
 }
 
-func GetByParams(filter UserFilter, page, limit int) ([]models.User, error) {
+func (r *GormUserRepository) ListAfter(ctx context.Context, filter UserFilter, afterID uint, limit int) ([]models.User, error) {
 	var users []models.User
-	offset := (page - 1) * limit
 
-	query := database.DB.Model(&models.User{})
+	query := applyFilter(r.db.WithContext(ctx).Model(&models.User{}), filter)
+
+	res := query.Where("id > ?", afterID).Order("id").Limit(limit).Find(&users)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	return users, nil
+}
+
+// Stream reads the users with a single query in a read-only snapshot, so an export never mixes
+// rows from before and after a concurrent change, and scans them one by one as fn consumes them.
+func (r *GormUserRepository) Stream(ctx context.Context, filter UserFilter, fn func(user *models.User) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Filtering in a subquery keeps the filter's unqualified columns apart from the joined metadata.
+		matching := applyFilter(tx.Session(&gorm.Session{NewDB: true}).Model(&models.User{}), filter).Select("id")
+		rows, err := tx.Model(&models.User{}).Unscoped().Joins("Enrichment").
+			Where("users.id IN (?)", matching).Order("users.id").Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 
+		for rows.Next() {
+			var user models.User
+			if err := tx.ScanRows(rows, &user); err != nil {
+				return err
+			}
+			if err := fn(&user); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+}
+
+func applyFilter(query *gorm.DB, filter UserFilter) *gorm.DB {
+	enrichments := func() *gorm.DB {
+		return query.Session(&gorm.Session{NewDB: true}).Model(&models.UserEnrichment{})
+	}
+	if filter.IncludeDeleted {
+		query = query.Unscoped()
+	}
 	if filter.Gender != "" {
 		query = query.Where("gender = ?", filter.Gender)
 	}
-	if filter.Nationality != "" {
-		query = query.Where("nationality = ?", filter.Nationality)
+	if len(filter.Nationalities) > 0 {
+		query = query.Where("nationality IN ?", filter.Nationalities)
+	}
+	if filter.Name != "" {
+		query = query.Where("name ILIKE ?", likePrefix(filter.Name))
+	}
+	if filter.Surname != "" {
+		query = query.Where("surname ILIKE ?", likePrefix(filter.Surname))
+	}
+	if filter.Search != "" {
+		query = query.Where("(name % ? OR surname % ? OR (name || ' ' || surname) % ?)", filter.Search, filter.Search, filter.Search)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		query = query.Where("updated_at >= ?", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		query = query.Where("updated_at < ?", *filter.UpdatedBefore)
+	}
+	if filter.NationalityMissing {
+		query = query.Where("nationality = ''")
 	}
 	if filter.AgeMin != nil {
 		query = query.Where("age >= ?", *filter.AgeMin)
@@ -51,11 +400,33 @@ func GetByParams(filter UserFilter, page, limit int) ([]models.User, error) {
 	if filter.AgeMax != nil {
 		query = query.Where("age <= ?", *filter.AgeMax)
 	}
+	if filter.EnrichmentStatus != "" {
+		query = query.Where("enrichment_status = ?", filter.EnrichmentStatus)
+	}
+	if filter.GenderProbabilityMin != nil {
+		query = query.Where("id IN (?)", enrichments().
+			Select("user_id").Where("gender_probability >= ?", *filter.GenderProbabilityMin))
+	}
+	if filter.NationalityProbabilityMin != nil {
+		query = query.Where("id IN (?)", enrichments().
+			Select("user_id").Where("nationality_probability >= ?", *filter.NationalityProbabilityMin))
+	}
+	if filter.EnrichedBefore != nil {
+		// Users without enrichment metadata were enriched before it was recorded.
+		query = query.Where("id NOT IN (?)", enrichments().
+			Select("user_id").Where("enriched_at >= ?", *filter.EnrichedBefore))
+	}
+	return query
+}
 
-	res := query.Limit(limit).Offset(offset).Find(&users)
-	if res.Error != nil {
-		return nil, res.Error
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
 	}
+	return err
+}
 
-	return users, nil
+// likePrefix turns s into a LIKE pattern matching values that start with s.
+func likePrefix(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s) + "%"
 }
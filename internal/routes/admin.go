@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// adminOnly guards the /admin endpoints. With a token set, requests must send it as
+// "Authorization: Bearer <token>"; without one, only clients on the loopback interface are let in.
+func adminOnly(token string) func(http.Handler) http.Handler {
+	want := sha256.Sum256([]byte(token))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				if !loopback(r.RemoteAddr) {
+					http.Error(w, "Admin endpoints are only served to localhost, set admin.token to allow other clients", http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sent, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			// Comparing digests keeps the time taken independent of the token's length.
+			got := sha256.Sum256([]byte(sent))
+			if !ok || subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				http.Error(w, "Invalid or missing admin token", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// loopback reports whether the request came from the same host. X-Forwarded-For is ignored
+// on purpose: behind a proxy every request looks local, so set admin.token there.
+func loopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsLoopback()
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminOnly(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		remoteAddr    string
+		authorization string
+		wantStatus    int
+	}{
+		{"no token, localhost", "", "127.0.0.1:50000", "", http.StatusOK},
+		{"no token, IPv6 localhost", "", "[::1]:50000", "", http.StatusOK},
+		{"no token, remote", "", "203.0.113.7:50000", "", http.StatusForbidden},
+		{"no token, remote with header", "", "203.0.113.7:50000", "Bearer ", http.StatusForbidden},
+		{"token", "s3cret", "203.0.113.7:50000", "Bearer s3cret", http.StatusOK},
+		{"token required on localhost", "s3cret", "127.0.0.1:50000", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "203.0.113.7:50000", "Bearer s3cre", http.StatusUnauthorized},
+		{"other scheme", "s3cret", "203.0.113.7:50000", "Basic s3cret", http.StatusUnauthorized},
+		{"empty bearer", "s3cret", "203.0.113.7:50000", "Bearer ", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := adminOnly(tt.token)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest(http.MethodGet, "/admin/jobs", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/go-chi/chi"
+	"net/http"
+	"strings"
+)
+
+// deprecated marks responses of a legacy route with a Deprecation header and a Link to the route
+// that replaces it. An {id} placeholder in successor is filled from the request's id URL or query parameter.
+func deprecated(successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			link := successor
+			if strings.Contains(link, "{id}") {
+				id := chi.URLParam(r, "id")
+				if id == "" {
+					id = r.URL.Query().Get("id")
+				}
+				link = strings.ReplaceAll(link, "{id}", id)
+			}
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", "<"+link+`>; rel="successor-version"`)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+)
+
+// LimitBody caps the size of request bodies at limit bytes, or at importLimit for the bulk
+// import endpoints. Reading past the cap fails, and handlers answer 413 Request Entity Too Large.
+func LimitBody(next http.Handler, limit, importLimit int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := limit
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/import") {
+			n = importLimit
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, n)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"TestTask/pkg/logger"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/go-chi/chi/middleware"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// requestIDHeader carries the request ID in both directions.
+const requestIDHeader = "X-Request-ID"
+
+// probePaths are polled by load balancers and scrapers; they are only logged at debug level
+// unless they fail, so they do not drown the other requests.
+var probePaths = map[string]bool{"/readyz": true, "/metrics": true}
+
+// requestID assigns every request an ID, taken from the X-Request-ID header when the client
+// sent a usable one, stores it in the request context for the log lines written while serving
+// it, echoes it in the response and logs the request once it is done.
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		ctx := logger.WithRequestID(r.Context(), id)
+		w.Header().Set(requestIDHeader, id)
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case probePaths[r.URL.Path]:
+			level = slog.LevelDebug
+		}
+		logger.Logger.Log(ctx, level, "Request served",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(start),
+		)
+	})
+}
+
+// validRequestID accepts short IDs of printable ASCII, so a client cannot inject into the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
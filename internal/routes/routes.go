@@ -1,20 +1,66 @@
 package routes
 
 import (
+	v1 "TestTask/internal/api/v1"
 	"TestTask/internal/handler"
+	"TestTask/internal/reload"
+	"TestTask/pkg/enrich"
+	"database/sql"
 	"github.com/go-chi/chi"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"net/http"
 )
 
-func SetupRoutes() http.Handler {
+func SetupRoutes(users *v1.Handler, jobs *handler.JobsHandler, enricher *enrich.Reloadable, reloader *reload.Reloader, db *sql.DB, adminToken string) http.Handler {
+	// apiVersions lists the mounted API versions. A new version gets its own package with
+	// request/response DTOs and a handler on top of internal/service, and an entry here;
+	// older versions keep serving unchanged.
+	apiVersions := map[string]func(r chi.Router){
+		"v1": users.Routes,
+	}
+
 	mux := chi.NewRouter()
+	mux.Use(requestID)
 	mux.Get("/swagger/*", httpSwagger.Handler(
-		httpSwagger.URL("http://localhost:8080/swagger/doc.json"),
+		httpSwagger.URL("/swagger/doc.json"),
 	))
-	mux.Post("/user", handler.CreateUser)
-	mux.Get("/user", handler.GetUsers)
-	mux.Put("/user", handler.UpdateUser)
-	mux.Delete("/user", handler.DeleteUser)
+
+	for version, routes := range apiVersions {
+		mux.Route("/api/"+version, routes)
+	}
+
+	// Deprecated unversioned aliases of the v1 routes, kept for existing clients.
+	mux.Route("/users", func(r chi.Router) {
+		r.With(deprecated("/api/v1/users")).Get("/", users.GetUsers)
+		r.With(deprecated("/api/v1/users")).Post("/", users.CreateUser)
+		r.With(deprecated("/api/v1/users/import")).Post("/import", users.ImportUsers)
+		r.With(deprecated("/api/v1/users/export")).Get("/export", users.ExportUsers)
+		r.With(deprecated("/api/v1/users/{id}")).Get("/{id}", users.GetUser)
+		r.With(deprecated("/api/v1/users/{id}")).Put("/{id}", users.UpdateUser)
+		r.With(deprecated("/api/v1/users/{id}")).Patch("/{id}", users.PatchUser)
+		r.With(deprecated("/api/v1/users/{id}")).Delete("/{id}", users.DeleteUser)
+		r.With(deprecated("/api/v1/users/{id}/enrich")).Post("/{id}/enrich", users.ReenrichUser)
+	})
+	mux.With(deprecated("/api/v1/users")).Get("/user", users.GetUsers)
+	mux.With(deprecated("/api/v1/users")).Post("/user", users.CreateUser)
+	mux.With(deprecated("/api/v1/users/{id}")).Put("/user", users.UpdateUser)
+	mux.With(deprecated("/api/v1/users/{id}")).Delete("/user", users.DeleteUser)
+	mux.With(deprecated("/api/v1/users/{id}")).Get("/user/{id}", users.GetUser)
+	mux.With(deprecated("/api/v1/users/{id}/enrich")).Post("/user/{id}/enrich", users.ReenrichUser)
+	mux.With(deprecated("/api/v1/users")).Post("/createuser", users.CreateUser)
+	mux.With(deprecated("/api/v1/users/{id}")).Put("/updateuser", users.UpdateUser)
+	mux.With(deprecated("/api/v1/users/{id}")).Delete("/deleteuser", users.DeleteUser)
+
+	mux.Get("/readyz", handler.Ready(db))
+	mux.Get("/metrics", handler.Metrics(db))
+	mux.Get("/enrichment/cache", handler.GetEnrichmentCacheStats(enricher))
+	mux.Route("/admin", func(r chi.Router) {
+		r.Use(adminOnly(adminToken))
+		r.Get("/jobs", jobs.GetJobs)
+		r.Post("/jobs/{id}/retry", jobs.RetryJob)
+		r.Post("/jobs/{id}/cancel", jobs.CancelJob)
+		r.Get("/config/reload", handler.GetConfigReload(reloader))
+		r.Post("/config/reload", handler.ReloadConfig(reloader))
+	})
 	return mux
 }
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// purgeBatch bounds the number of users erased per transaction by the scheduled purge.
+const purgeBatch = 500
+
+// StartPurger permanently erases users that have been soft-deleted for longer than retention,
+// checking every interval. A non-positive retention disables the purge.
+func (s *UserService) StartPurger(retention, interval time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	s.purgeMu.Lock()
+	defer s.purgeMu.Unlock()
+	if s.purgeStop != nil {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.purgeStop, s.purgeDone = stop, done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.purgeDeleted(time.Now().Add(-retention))
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	s.log.Info("Purging deleted users", "retention", retention, "interval", interval)
+}
+
+// StopPurger stops the scheduled purge and waits for a running purge to finish.
+func (s *UserService) StopPurger() {
+	s.purgeMu.Lock()
+	defer s.purgeMu.Unlock()
+	if s.purgeStop == nil {
+		return
+	}
+	close(s.purgeStop)
+	<-s.purgeDone
+	s.purgeStop, s.purgeDone = nil, nil
+}
+
+func (s *UserService) purgeDeleted(before time.Time) {
+	var total int64
+	for {
+		purged, err := s.users.PurgeDeleted(context.Background(), before, purgeBatch)
+		if err != nil {
+			s.log.Error("Could not purge deleted users", "err", err)
+			return
+		}
+		total += purged
+		if purged < purgeBatch {
+			break
+		}
+	}
+	if total > 0 {
+		s.log.Info("Purged deleted users", "purged", total)
+	}
+}
@@ -0,0 +1,297 @@
+// Package service holds the user operations shared by every API version.
+// Handlers decode their own request DTOs, call a UserService with plain values
+// and map the returned models to their own response DTOs.
+package service
+
+import (
+	"TestTask/internal/enrichment"
+	"TestTask/internal/importer"
+	"TestTask/internal/models"
+	"TestTask/internal/repository"
+	"TestTask/pkg/enrich"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// ErrNotFound is returned when no user has the requested id.
+var ErrNotFound = errors.New("user not found")
+
+// ErrNotDeleted is returned when restoring a user that is not deleted.
+var ErrNotDeleted = errors.New("user is not deleted")
+
+// ErrVersionMismatch is returned by conditional writes when the user has changed since the given version.
+var ErrVersionMismatch = errors.New("user was modified concurrently")
+
+// ValidationError describes input that a handler should reject with 400 Bad Request.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return e.Message }
+
+// UserFields are the user attributes that can be set by clients.
+type UserFields struct {
+	Name        string
+	Surname     string
+	Age         int
+	Gender      string
+	Nationality string
+}
+
+// Enricher looks up the age, gender and nationality for a first name; *enrich.Enricher implements it.
+type Enricher interface {
+	// Enrich may answer from a cache, Refresh always asks the providers.
+	Enrich(ctx context.Context, name string) (*enrich.Enriched, error)
+	Refresh(ctx context.Context, name string) (*enrich.Enriched, error)
+}
+
+// Queue enriches users in the background; *enrichment.Pool implements it.
+type Queue interface {
+	// CreatePending stores user as pending and queues its enrichment.
+	CreatePending(ctx context.Context, user *models.User) error
+}
+
+// UserService implements the user operations on top of a repository and an enricher.
+type UserService struct {
+	users    repository.UserRepository
+	enricher Enricher
+	queue    Queue
+	log      *slog.Logger
+
+	purgeMu   sync.Mutex
+	purgeStop chan struct{}
+	purgeDone chan struct{}
+}
+
+// NewUserService returns a UserService storing users in users and enriching them with enricher.
+// A nil queue enriches new users synchronously.
+func NewUserService(users repository.UserRepository, enricher Enricher, queue Queue, log *slog.Logger) *UserService {
+	return &UserService{users: users, enricher: enricher, queue: queue, log: log}
+}
+
+// CreateUser stores a new user. With a queue the user is saved as pending and enriched later;
+// otherwise it is enriched before it is saved and enrichment errors are returned.
+func (s *UserService) CreateUser(ctx context.Context, name, surname string) (*models.User, error) {
+	if name == "" || surname == "" {
+		return nil, &ValidationError{Message: "Name and surname are required"}
+	}
+
+	user := models.User{
+		Name:    name,
+		Surname: surname,
+	}
+	if s.queue != nil {
+		if err := s.queue.CreatePending(ctx, &user); err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+
+	enriched, err := s.enricher.Enrich(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	enrichment.Apply(&user, enriched)
+
+	if err := s.users.Create(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser returns the user with its enrichment metadata.
+func (s *UserService) GetUser(ctx context.Context, id int) (*models.User, error) {
+	user, err := s.users.GetByID(ctx, uint(id))
+	return user, notFound(err)
+}
+
+// ListUsers returns one page of users matching filter.
+func (s *UserService) ListUsers(ctx context.Context, filter repository.UserFilter, page repository.PageRequest) ([]models.User, repository.PageInfo, error) {
+	users, info, err := s.users.List(ctx, filter, page)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return nil, info, &ValidationError{Message: "Invalid cursor, it may belong to a different sort"}
+	}
+	return users, info, err
+}
+
+// StreamUsers calls fn for every user matching filter, in id order.
+func (s *UserService) StreamUsers(ctx context.Context, filter repository.UserFilter, fn func(user *models.User) error) error {
+	return s.users.Stream(ctx, filter, fn)
+}
+
+// UserStats aggregates the users matching filter, grouping ages into buckets of bucketWidth years.
+func (s *UserService) UserStats(ctx context.Context, filter repository.UserFilter, bucketWidth int) (*repository.UserStats, error) {
+	return s.users.Stats(ctx, filter, bucketWidth)
+}
+
+// ImportUsers enriches and stores the users read from r; see importer.Import.
+func (s *UserService) ImportUsers(ctx context.Context, r io.Reader, format string, opts importer.Options) (*importer.Report, error) {
+	opts.Users, opts.Enricher = s.users, s.enricher
+	return importer.Import(ctx, r, format, opts)
+}
+
+// UpdateUser replaces the client-settable attributes of a user. A non-zero version makes the
+// update conditional: ErrVersionMismatch is returned if the user has changed since.
+func (s *UserService) UpdateUser(ctx context.Context, id int, version uint, fields UserFields) (*models.User, error) {
+	if fields.Name == "" || fields.Surname == "" {
+		return nil, &ValidationError{Message: "Name and surname are required"}
+	}
+
+	return s.PatchUser(ctx, id, version, map[string]interface{}{
+		"name":        fields.Name,
+		"surname":     fields.Surname,
+		"age":         fields.Age,
+		"gender":      fields.Gender,
+		"nationality": fields.Nationality,
+	})
+}
+
+// Patchable columns and their types, the allowlist for PatchUser.
+var patchableColumns = map[string]func(v interface{}) bool{
+	"name":        isString,
+	"surname":     isString,
+	"age":         isInt,
+	"gender":      isString,
+	"nationality": isString,
+}
+
+// PatchUser updates only the given columns of a user. Keys must be in the allowlist above and
+// values must be strings, or an int for age. Name and surname cannot be cleared and gender
+// must be male, female or empty.
+// A non-zero version makes the update conditional like in UpdateUser.
+func (s *UserService) PatchUser(ctx context.Context, id int, version uint, changes map[string]interface{}) (*models.User, error) {
+	for column, value := range changes {
+		valid, ok := patchableColumns[column]
+		if !ok {
+			return nil, &ValidationError{Message: "Field " + column + " cannot be changed"}
+		}
+		if !valid(value) {
+			return nil, &ValidationError{Message: "Invalid value for " + column}
+		}
+		if (column == "name" || column == "surname") && value == "" {
+			return nil, &ValidationError{Message: "Name and surname are required"}
+		}
+		if column == "gender" && !models.ValidGender(value.(string)) {
+			return nil, &ValidationError{Message: "Gender must be " + models.GenderMale + " or " + models.GenderFemale}
+		}
+	}
+
+	if len(changes) > 0 {
+		updated, err := s.users.UpdateFields(ctx, uint(id), version, changes)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			return nil, s.missingOrChanged(ctx, id, version)
+		}
+	}
+
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 && version > 0 && user.Version != version {
+		return nil, ErrVersionMismatch
+	}
+	return user, nil
+}
+
+func isString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+func isInt(v interface{}) bool {
+	_, ok := v.(int)
+	return ok
+}
+
+// DeleteUser soft-deletes a user: it is hidden from reads until restored or purged.
+// A non-zero version makes the delete conditional like in UpdateUser.
+func (s *UserService) DeleteUser(ctx context.Context, id int, version uint) error {
+	deleted, err := s.users.Delete(ctx, uint(id), version)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return s.missingOrChanged(ctx, id, version)
+	}
+	return nil
+}
+
+// PurgeUser permanently erases a user and its enrichment data, whether or not it is soft-deleted.
+// A non-zero version makes the purge conditional like in UpdateUser.
+func (s *UserService) PurgeUser(ctx context.Context, id int, version uint) error {
+	purged, err := s.users.Purge(ctx, uint(id), version)
+	if err != nil {
+		return err
+	}
+	if !purged {
+		if _, err := s.users.GetByIDUnscoped(ctx, uint(id)); err != nil || version == 0 {
+			return ErrNotFound
+		}
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// RestoreUser undoes the soft delete of a user.
+func (s *UserService) RestoreUser(ctx context.Context, id int) (*models.User, error) {
+	restored, err := s.users.Restore(ctx, uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		if _, err := s.users.GetByIDUnscoped(ctx, uint(id)); err != nil {
+			return nil, notFound(err)
+		}
+		return nil, ErrNotDeleted
+	}
+	return s.GetUser(ctx, id)
+}
+
+// ReenrichUser looks up fresh attributes for a user, bypassing the enrichment cache.
+func (s *UserService) ReenrichUser(ctx context.Context, id int) (*models.User, error) {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Reenrich(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Reenrich looks up fresh attributes for an existing user, bypassing the cache, and saves them.
+// On failure the user keeps its current values. Attributes a client edits during the lookup
+// keep the client's values.
+func (s *UserService) Reenrich(ctx context.Context, user *models.User) error {
+	base := *user
+	enriched, err := s.enricher.Refresh(ctx, user.Name)
+	if err != nil {
+		return err
+	}
+	enrichment.Apply(user, enriched)
+	return notFound(s.users.SaveEnrichment(ctx, &base, user))
+}
+
+// missingOrChanged explains why a conditional write affected no row.
+func (s *UserService) missingOrChanged(ctx context.Context, id int, version uint) error {
+	if version == 0 {
+		return ErrNotFound
+	}
+	if _, err := s.users.GetByID(ctx, uint(id)); err != nil {
+		return notFound(err)
+	}
+	return ErrVersionMismatch
+}
+
+func notFound(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling a provider while its circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Breaker stops calling a provider after Threshold consecutive failures and lets a single
+// trial call through once Cooldown has passed. Threshold zero disables the breaker.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker creates a closed circuit breaker.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+func (b *Breaker) open() bool {
+	return b.Threshold > 0 && b.failures >= b.Threshold
+}
+
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open() {
+		return true
+	}
+	if b.probing || time.Since(b.openedAt) < b.Cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	// Sibling lookups cancelled after another provider failed say nothing about this provider's health.
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	b.failures++
+	if b.open() {
+		b.openedAt = time.Now()
+	}
+}
+
+// call runs fn through the breaker and retry policy.
+func call[T any](ctx context.Context, b *Breaker, retry RetryPolicy, fn func(ctx context.Context) (T, error)) (T, error) {
+	var res T
+	if b != nil && !b.allow() {
+		return res, ErrCircuitOpen
+	}
+	err := retry.do(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = fn(ctx)
+		return err
+	})
+	if b != nil {
+		b.record(err)
+	}
+	return res, err
+}
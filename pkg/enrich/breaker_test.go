@@ -0,0 +1,101 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	fail := errors.New("upstream down")
+	type step struct {
+		wait      bool  // let the cooldown pass before the call
+		err       error // outcome of the call if it is allowed
+		wantAllow bool
+	}
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []step
+	}{
+		{"opens after threshold", 2, []step{
+			{err: fail, wantAllow: true},
+			{err: fail, wantAllow: true},
+			{wantAllow: false},
+		}},
+		{"success resets the count", 2, []step{
+			{err: fail, wantAllow: true},
+			{err: nil, wantAllow: true},
+			{err: fail, wantAllow: true},
+			{wantAllow: true},
+		}},
+		{"successful trial closes", 1, []step{
+			{err: fail, wantAllow: true},
+			{wantAllow: false},
+			{wait: true, err: nil, wantAllow: true},
+			{err: fail, wantAllow: true},
+		}},
+		{"failed trial reopens", 1, []step{
+			{err: fail, wantAllow: true},
+			{wait: true, err: fail, wantAllow: true},
+			{wantAllow: false},
+		}},
+		{"cancellations are not failures", 1, []step{
+			{err: context.Canceled, wantAllow: true},
+			{err: context.Canceled, wantAllow: true},
+			{wantAllow: true},
+		}},
+		{"disabled", 0, []step{
+			{err: fail, wantAllow: true},
+			{err: fail, wantAllow: true},
+			{wantAllow: true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreaker(tt.threshold, time.Minute)
+			for i, s := range tt.steps {
+				if s.wait {
+					b.openedAt = b.openedAt.Add(-time.Minute)
+				}
+				if got := b.allow(); got != s.wantAllow {
+					t.Fatalf("step %d: allow() = %v, want %v", i, got, s.wantAllow)
+				}
+				if s.wantAllow {
+					b.record(s.err)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerSingleTrial(t *testing.T) {
+	b := NewBreaker(1, time.Minute)
+	b.allow()
+	b.record(errors.New("upstream down"))
+	b.openedAt = b.openedAt.Add(-time.Minute)
+
+	if !b.allow() {
+		t.Fatal("first call after the cooldown was rejected")
+	}
+	if b.allow() {
+		t.Fatal("a second call was let through while the trial is running")
+	}
+}
+
+func TestCallShortCircuits(t *testing.T) {
+	b := NewBreaker(1, time.Minute)
+	calls := 0
+	fn := func(context.Context) (int, error) {
+		calls++
+		return 0, errors.New("upstream down")
+	}
+	call(context.Background(), b, RetryPolicy{}, fn)
+	if _, err := call(context.Background(), b, RetryPolicy{}, fn); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("call() = %v, want ErrCircuitOpen", err)
+	}
+	if calls != 1 {
+		t.Fatalf("provider called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,194 @@
+package enrich
+
+import (
+	"TestTask/internal/models"
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores enrichment results keyed by normalized first name.
+type Cache interface {
+	Get(ctx context.Context, key string) (*Enriched, bool, error)
+	Set(ctx context.Context, key string, value *Enriched) error
+}
+
+// CacheStats counts cache lookups made by an Enricher.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// NormalizeName turns a first name into a cache key, so "Dmitriy" and " dmitriy" share an entry.
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// MemoryCache is an in-process LRU cache with a fixed capacity and a per-entry TTL.
+type MemoryCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	value   *Enriched
+	expires time.Time
+}
+
+// NewMemoryCache creates an LRU cache holding at most size entries.
+// A zero ttl keeps entries until they are evicted.
+func NewMemoryCache(size int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string) (*Enriched, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return nil, false, nil
+	}
+	c.ll.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+func (c *MemoryCache) Set(_ context.Context, key string, value *Enriched) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = time.Now().Add(c.ttl)
+	}
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.value = value
+		entry.expires = expires
+		c.ll.MoveToFront(el)
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryEntry{key: key, value: value, expires: expires})
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// PostgresCache persists enrichment results in the enrichment_cache table so they survive restarts.
+type PostgresCache struct {
+	db  *gorm.DB
+	ttl time.Duration
+}
+
+// NewPostgresCache creates a cache backed by db. A zero ttl keeps entries forever.
+func NewPostgresCache(db *gorm.DB, ttl time.Duration) *PostgresCache {
+	return &PostgresCache{db: db, ttl: ttl}
+}
+
+func (c *PostgresCache) Get(ctx context.Context, key string) (*Enriched, bool, error) {
+	var entry models.EnrichmentCacheEntry
+	err := c.db.WithContext(ctx).
+		Where("name = ? AND (expires_at IS NULL OR expires_at > ?)", key, time.Now()).
+		Take(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var value Enriched
+	if err := json.Unmarshal([]byte(entry.Value), &value); err != nil {
+		return nil, false, err
+	}
+	return &value, true, nil
+}
+
+func (c *PostgresCache) Set(ctx context.Context, key string, value *Enriched) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	entry := models.EnrichmentCacheEntry{Name: key, Value: string(data)}
+	if c.ttl > 0 {
+		expires := time.Now().Add(c.ttl)
+		entry.ExpiresAt = &expires
+	}
+	return c.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&entry).Error
+}
+
+// Layered checks caches in order and copies a hit into the layers in front of it.
+// Nil layers are skipped, so Layered(nil, c) behaves like c.
+func Layered(caches ...Cache) Cache {
+	var layers layeredCache
+	for _, c := range caches {
+		if c != nil {
+			layers = append(layers, c)
+		}
+	}
+	if len(layers) == 0 {
+		return nil
+	}
+	if len(layers) == 1 {
+		return layers[0]
+	}
+	return layers
+}
+
+type layeredCache []Cache
+
+func (l layeredCache) Get(ctx context.Context, key string) (*Enriched, bool, error) {
+	for i, c := range l {
+		value, ok, err := c.Get(ctx, key)
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			continue
+		}
+		for _, front := range l[:i] {
+			if err := front.Set(ctx, key, value); err != nil {
+				return nil, false, err
+			}
+		}
+		return value, true, nil
+	}
+	return nil, false, nil
+}
+
+func (l layeredCache) Set(ctx context.Context, key string, value *Enriched) error {
+	var errs []error
+	for _, c := range l {
+		if err := c.Set(ctx, key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -2,63 +2,69 @@ package enrich
 
 import (
 	"TestTask/internal/config"
+	"context"
 	"encoding/json"
-	"fmt"
+	"gorm.io/gorm"
 	"net/http"
+	"time"
 )
 
-var cfg *config.Config = config.LoadYaml("config.yaml")
+// httpClient is shared by the built-in providers instead of http.DefaultClient.
+// Request deadlines come from the context; the client timeout is only a safety net.
+var httpClient = &http.Client{
+	Timeout:   time.Minute,
+	Transport: http.DefaultTransport.(*http.Transport).Clone(),
+}
 
+// Enriched is the result of enriching a name: the top guess per attribute
+// together with the confidence reported by the providers that produced it.
 type Enriched struct {
 	Age         int
 	Gender      string
 	Nationality string
-}
 
-func EnrichData(name string) (*Enriched, error) {
-	type ageResp struct{ Age int }
-	type genderResp struct{ Gender string }
-	type natResp struct {
-		Country []struct {
-			CountryID   string `json:"country_id"`
-			Probability float64
-		}
-	}
+	AgeCount               int
+	GenderProbability      float64
+	GenderCount            int
+	NationalityProbability float64
+	NationalityCount       int
+	Countries              []Country
 
-	var (
-		ageData    ageResp
-		genderData genderResp
-		natData    natResp
-	)
+	AgeProvider         string
+	GenderProvider      string
+	NationalityProvider string
+	EnrichedAt          time.Time
+}
 
-	if err := fetchJSON(fmt.Sprintf(cfg.URL.Age, name), &ageData); err != nil {
-		return nil, err
-	}
-	if err := fetchJSON(fmt.Sprintf(cfg.URL.Gender, name), &genderData); err != nil {
-		return nil, err
-	}
-	if err := fetchJSON(fmt.Sprintf(cfg.URL.Nationality, name), &natData); err != nil {
+// New builds the Enricher configured by cfg. With cache.postgres enabled, results are also
+// cached in db, which may be nil to keep the cache in memory only.
+func New(cfg *config.Config, db *gorm.DB) (*Enricher, error) {
+	e, err := NewEnricher(cfg)
+	if err != nil {
 		return nil, err
 	}
-
-	nationality := ""
-	if len(natData.Country) > 0 {
-		nationality = natData.Country[0].CountryID
+	if cfg.Cache.Postgres && db != nil {
+		e.Cache = Layered(e.Cache, NewPostgresCache(db, cfg.Cache.TTL))
 	}
-
-	return &Enriched{
-		Age:         ageData.Age,
-		Gender:      genderData.Gender,
-		Nationality: nationality,
-	}, nil
+	return e, nil
 }
 
-func fetchJSON(url string, target interface{}) error {
-	resp, err := http.Get(url)
+func fetchJSON(ctx context.Context, client *http.Client, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	return json.NewDecoder(resp.Body).Decode(target)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newStatusError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return &DecodeError{Err: err}
+	}
+	return nil
 }
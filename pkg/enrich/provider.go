@@ -0,0 +1,329 @@
+package enrich
+
+import (
+	"TestTask/internal/config"
+	"TestTask/pkg/logger"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AgeEstimate is an age guess and the number of samples it is based on.
+type AgeEstimate struct {
+	Age   int
+	Count int
+}
+
+// GenderEstimate is a gender guess with its probability and sample count.
+type GenderEstimate struct {
+	Gender      string
+	Probability float64
+	Count       int
+}
+
+// NationalityEstimate holds candidate countries ranked by probability, highest first.
+type NationalityEstimate struct {
+	Countries []Country
+	Count     int
+}
+
+// Country is a single nationality candidate.
+type Country struct {
+	CountryID   string
+	Probability float64
+}
+
+// AgeProvider estimates the age of a person by first name.
+type AgeProvider interface {
+	Name() string
+	Age(ctx context.Context, name string) (*AgeEstimate, error)
+}
+
+// GenderProvider estimates the gender of a person by first name.
+type GenderProvider interface {
+	Name() string
+	Gender(ctx context.Context, name string) (*GenderEstimate, error)
+}
+
+// NationalityProvider estimates the nationality (ISO country code) of a person by first name.
+type NationalityProvider interface {
+	Name() string
+	Nationality(ctx context.Context, name string) (*NationalityEstimate, error)
+}
+
+// Factories build a provider from the service configuration.
+type (
+	AgeFactory         func(cfg *config.Config) (AgeProvider, error)
+	GenderFactory      func(cfg *config.Config) (GenderProvider, error)
+	NationalityFactory func(cfg *config.Config) (NationalityProvider, error)
+)
+
+type registry[F any] struct {
+	mu        sync.RWMutex
+	factories map[string]F
+}
+
+func (r *registry[F]) register(kind, name string, factory F) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.factories == nil {
+		r.factories = make(map[string]F)
+	}
+	if _, ok := r.factories[name]; ok {
+		panic(fmt.Sprintf("enrich: %s provider %q registered twice", kind, name))
+	}
+	r.factories[name] = factory
+}
+
+func (r *registry[F]) lookup(kind, name string) (F, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.factories[name]
+	if !ok {
+		var zero F
+		return zero, fmt.Errorf("enrich: unknown %s provider %q", kind, name)
+	}
+	return factory, nil
+}
+
+var (
+	ageProviders         registry[AgeFactory]
+	genderProviders      registry[GenderFactory]
+	nationalityProviders registry[NationalityFactory]
+)
+
+// RegisterAge makes an age provider available under name for selection in config.yaml.
+// It panics if a provider with the same name is already registered.
+func RegisterAge(name string, factory AgeFactory) {
+	ageProviders.register("age", name, factory)
+}
+
+// RegisterGender makes a gender provider available under name for selection in config.yaml.
+// It panics if a provider with the same name is already registered.
+func RegisterGender(name string, factory GenderFactory) {
+	genderProviders.register("gender", name, factory)
+}
+
+// RegisterNationality makes a nationality provider available under name for selection in config.yaml.
+// It panics if a provider with the same name is already registered.
+func RegisterNationality(name string, factory NationalityFactory) {
+	nationalityProviders.register("nationality", name, factory)
+}
+
+// Enricher combines one provider per attribute.
+// Zero timeouts mean no deadline beyond the one carried by the caller's context.
+type Enricher struct {
+	Age         AgeProvider
+	Gender      GenderProvider
+	Nationality NationalityProvider
+
+	Timeout            time.Duration
+	AgeTimeout         time.Duration
+	GenderTimeout      time.Duration
+	NationalityTimeout time.Duration
+
+	// Cache, when set, is consulted before the providers are called.
+	Cache Cache
+
+	// Retry applies to every provider call; each provider has its own breaker.
+	Retry              RetryPolicy
+	AgeBreaker         *Breaker
+	GenderBreaker      *Breaker
+	NationalityBreaker *Breaker
+
+	// counts is shared with the Enrichers replacing this one on reload, see Reloadable.
+	counts *cacheCounts
+}
+
+type cacheCounts struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewEnricher builds an Enricher from the providers selected in cfg.
+// Providers that are not configured fall back to the public agify/genderize/nationalize services.
+func NewEnricher(cfg *config.Config) (*Enricher, error) {
+	ageFactory, err := ageProviders.lookup("age", orDefault(cfg.Providers.Age, AgifyProvider))
+	if err != nil {
+		return nil, err
+	}
+	genderFactory, err := genderProviders.lookup("gender", orDefault(cfg.Providers.Gender, GenderizeProvider))
+	if err != nil {
+		return nil, err
+	}
+	nationalityFactory, err := nationalityProviders.lookup("nationality", orDefault(cfg.Providers.Nationality, NationalizeProvider))
+	if err != nil {
+		return nil, err
+	}
+
+	e := Enricher{
+		Timeout:            cfg.Timeouts.Overall,
+		AgeTimeout:         cfg.Timeouts.Age,
+		GenderTimeout:      cfg.Timeouts.Gender,
+		NationalityTimeout: cfg.Timeouts.Nationality,
+		Retry: RetryPolicy{
+			MaxAttempts: cfg.Retry.Attempts,
+			BaseDelay:   cfg.Retry.BaseDelay,
+			MaxDelay:    cfg.Retry.MaxDelay,
+		},
+		AgeBreaker:         NewBreaker(cfg.Breaker.Failures, cfg.Breaker.Cooldown),
+		GenderBreaker:      NewBreaker(cfg.Breaker.Failures, cfg.Breaker.Cooldown),
+		NationalityBreaker: NewBreaker(cfg.Breaker.Failures, cfg.Breaker.Cooldown),
+		counts:             new(cacheCounts),
+	}
+	if cfg.Cache.Size > 0 {
+		e.Cache = NewMemoryCache(cfg.Cache.Size, cfg.Cache.TTL)
+	}
+	if e.Age, err = ageFactory(cfg); err != nil {
+		return nil, err
+	}
+	if e.Gender, err = genderFactory(cfg); err != nil {
+		return nil, err
+	}
+	if e.Nationality, err = nationalityFactory(cfg); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Enrich returns the cached result for name or looks up age, gender and nationality concurrently.
+// The first failing lookup cancels the others. Cache errors are logged and treated as misses.
+func (e *Enricher) Enrich(ctx context.Context, name string) (*Enriched, error) {
+	key := NormalizeName(name)
+	if e.Cache != nil {
+		cached, ok, err := e.Cache.Get(ctx, key)
+		if err != nil {
+			logger.Logger.WarnContext(ctx, "Enrichment cache lookup failed", "name", key, "err", err)
+		}
+		if ok {
+			if e.counts != nil {
+				e.counts.hits.Add(1)
+			}
+			return cached, nil
+		}
+		if e.counts != nil {
+			e.counts.misses.Add(1)
+		}
+	}
+	return e.Refresh(ctx, name)
+}
+
+// Refresh looks up name at the providers even if it is cached and replaces the cached result.
+func (e *Enricher) Refresh(ctx context.Context, name string) (*Enriched, error) {
+	res, err := e.lookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if e.Cache != nil {
+		key := NormalizeName(name)
+		if err := e.Cache.Set(ctx, key, res); err != nil {
+			logger.Logger.WarnContext(ctx, "Could not cache enrichment", "name", key, "err", err)
+		}
+	}
+	return res, nil
+}
+
+// CacheStats reports how many Enrich calls were served from the cache.
+func (e *Enricher) CacheStats() CacheStats {
+	if e.counts == nil {
+		return CacheStats{}
+	}
+	return CacheStats{Hits: e.counts.hits.Load(), Misses: e.counts.misses.Load()}
+}
+
+func (e *Enricher) lookup(ctx context.Context, name string) (*Enriched, error) {
+	start := time.Now()
+	ctx, cancel := withTimeout(ctx, e.Timeout)
+	defer cancel()
+
+	var (
+		res = Enriched{
+			AgeProvider:         e.Age.Name(),
+			GenderProvider:      e.Gender.Name(),
+			NationalityProvider: e.Nationality.Name(),
+		}
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(provider string, err error) {
+		errOnce.Do(func() {
+			firstErr = fmt.Errorf("%s: %w", provider, err)
+			cancel()
+		})
+	}
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		ctx, cancel := withTimeout(ctx, e.AgeTimeout)
+		defer cancel()
+		age, err := call(ctx, e.AgeBreaker, e.Retry, func(ctx context.Context) (*AgeEstimate, error) {
+			return e.Age.Age(ctx, name)
+		})
+		if err != nil {
+			fail(e.Age.Name(), err)
+			return
+		}
+		res.Age = age.Age
+		res.AgeCount = age.Count
+	}()
+	go func() {
+		defer wg.Done()
+		ctx, cancel := withTimeout(ctx, e.GenderTimeout)
+		defer cancel()
+		gender, err := call(ctx, e.GenderBreaker, e.Retry, func(ctx context.Context) (*GenderEstimate, error) {
+			return e.Gender.Gender(ctx, name)
+		})
+		if err != nil {
+			fail(e.Gender.Name(), err)
+			return
+		}
+		res.Gender = gender.Gender
+		res.GenderProbability = gender.Probability
+		res.GenderCount = gender.Count
+	}()
+	go func() {
+		defer wg.Done()
+		ctx, cancel := withTimeout(ctx, e.NationalityTimeout)
+		defer cancel()
+		nationality, err := call(ctx, e.NationalityBreaker, e.Retry, func(ctx context.Context) (*NationalityEstimate, error) {
+			return e.Nationality.Nationality(ctx, name)
+		})
+		if err != nil {
+			fail(e.Nationality.Name(), err)
+			return
+		}
+		res.Countries = nationality.Countries
+		res.NationalityCount = nationality.Count
+		if len(nationality.Countries) > 0 {
+			res.Nationality = nationality.Countries[0].CountryID
+			res.NationalityProbability = nationality.Countries[0].Probability
+		}
+	}()
+	wg.Wait()
+
+	if firstErr != nil {
+		logger.Logger.WarnContext(ctx, "Enrichment lookup failed", "name", name, "duration", time.Since(start), "err", firstErr)
+		return nil, firstErr
+	}
+	res.EnrichedAt = time.Now().UTC()
+	logger.Logger.DebugContext(ctx, "Enrichment looked up", "name", name, "duration", time.Since(start))
+	return &res, nil
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+func orDefault(name, def string) string {
+	if name == "" {
+		return def
+	}
+	return name
+}
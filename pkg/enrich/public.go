@@ -0,0 +1,110 @@
+package enrich
+
+import (
+	"TestTask/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// Names of the built-in providers backed by the public agify.io, genderize.io and nationalize.io APIs.
+const (
+	AgifyProvider       = "agify"
+	GenderizeProvider   = "genderize"
+	NationalizeProvider = "nationalize"
+)
+
+func init() {
+	RegisterAge(AgifyProvider, func(cfg *config.Config) (AgeProvider, error) {
+		if cfg.URL.Age == "" {
+			return nil, errors.New("enrich: url.age is not configured")
+		}
+		return &agify{client: httpClient, url: cfg.URL.Age}, nil
+	})
+	RegisterGender(GenderizeProvider, func(cfg *config.Config) (GenderProvider, error) {
+		if cfg.URL.Gender == "" {
+			return nil, errors.New("enrich: url.gender is not configured")
+		}
+		return &genderize{client: httpClient, url: cfg.URL.Gender}, nil
+	})
+	RegisterNationality(NationalizeProvider, func(cfg *config.Config) (NationalityProvider, error) {
+		if cfg.URL.Nationality == "" {
+			return nil, errors.New("enrich: url.nationality is not configured")
+		}
+		return &nationalize{client: httpClient, url: cfg.URL.Nationality}, nil
+	})
+}
+
+// nameURL fills the name into the configured URL template, escaped so it stays one query value.
+func nameURL(template, name string) string {
+	return fmt.Sprintf(template, url.QueryEscape(name))
+}
+
+type agify struct {
+	url    string
+	client *http.Client
+}
+
+func (p *agify) Name() string { return AgifyProvider }
+
+func (p *agify) Age(ctx context.Context, name string) (*AgeEstimate, error) {
+	var resp struct {
+		Age   int
+		Count int
+	}
+	if err := fetchJSON(ctx, p.client, nameURL(p.url, name), &resp); err != nil {
+		return nil, err
+	}
+	return &AgeEstimate{Age: resp.Age, Count: resp.Count}, nil
+}
+
+type genderize struct {
+	url    string
+	client *http.Client
+}
+
+func (p *genderize) Name() string { return GenderizeProvider }
+
+func (p *genderize) Gender(ctx context.Context, name string) (*GenderEstimate, error) {
+	var resp struct {
+		Gender      string
+		Probability float64
+		Count       int
+	}
+	if err := fetchJSON(ctx, p.client, nameURL(p.url, name), &resp); err != nil {
+		return nil, err
+	}
+	return &GenderEstimate{Gender: resp.Gender, Probability: resp.Probability, Count: resp.Count}, nil
+}
+
+type nationalize struct {
+	url    string
+	client *http.Client
+}
+
+func (p *nationalize) Name() string { return NationalizeProvider }
+
+func (p *nationalize) Nationality(ctx context.Context, name string) (*NationalityEstimate, error) {
+	var resp struct {
+		Count   int
+		Country []struct {
+			CountryID   string `json:"country_id"`
+			Probability float64
+		}
+	}
+	if err := fetchJSON(ctx, p.client, nameURL(p.url, name), &resp); err != nil {
+		return nil, err
+	}
+
+	countries := make([]Country, 0, len(resp.Country))
+	for _, c := range resp.Country {
+		countries = append(countries, Country{CountryID: c.CountryID, Probability: c.Probability})
+	}
+	sort.SliceStable(countries, func(i, j int) bool {
+		return countries[i].Probability > countries[j].Probability
+	})
+	return &NationalityEstimate{Countries: countries, Count: resp.Count}, nil
+}
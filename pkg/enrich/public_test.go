@@ -0,0 +1,65 @@
+package enrich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPublicProvidersEscapeName(t *testing.T) {
+	names := []string{
+		"Dmitriy",
+		"Anne Marie",
+		"Tom&country_id=US",
+		"Jo#anna",
+		"Who?",
+		"100%",
+		"Zoë+Ann",
+		"Ольга",
+	}
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if len(q) != 1 || len(q["name"]) != 1 {
+			t.Errorf("query %q: want exactly one name parameter", r.URL.RawQuery)
+		}
+		got = append(got, q.Get("name"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"age":40,"gender":"male","probability":0.9,"count":10,"country":[{"country_id":"RU","probability":0.5}]}`))
+	}))
+	defer srv.Close()
+
+	template := srv.URL + "/?name=%s"
+	providers := []struct {
+		name   string
+		lookup func(ctx context.Context, name string) error
+	}{
+		{AgifyProvider, func(ctx context.Context, name string) error {
+			_, err := (&agify{client: srv.Client(), url: template}).Age(ctx, name)
+			return err
+		}},
+		{GenderizeProvider, func(ctx context.Context, name string) error {
+			_, err := (&genderize{client: srv.Client(), url: template}).Gender(ctx, name)
+			return err
+		}},
+		{NationalizeProvider, func(ctx context.Context, name string) error {
+			_, err := (&nationalize{client: srv.Client(), url: template}).Nationality(ctx, name)
+			return err
+		}},
+	}
+	for _, p := range providers {
+		for _, name := range names {
+			t.Run(p.name+"/"+name, func(t *testing.T) {
+				got = nil
+				if err := p.lookup(context.Background(), name); err != nil {
+					t.Fatalf("lookup: %v", err)
+				}
+				if len(got) != 1 || got[0] != name {
+					t.Errorf("provider received name %q, want %q", got, name)
+				}
+			})
+		}
+	}
+}
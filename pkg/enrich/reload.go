@@ -0,0 +1,70 @@
+package enrich
+
+import (
+	"TestTask/internal/config"
+	"context"
+	"sync/atomic"
+)
+
+// Reloadable is an Enricher whose providers, timeouts, retry policy and breakers can be
+// replaced while it is in use. Lookups already running finish with the settings they
+// started with. The cache and its counters are kept across reloads, and so is the breaker of
+// each provider as long as neither the provider nor the breaker settings change.
+type Reloadable struct {
+	current atomic.Pointer[Enricher]
+}
+
+// NewReloadable returns a Reloadable starting with e.
+func NewReloadable(e *Enricher) *Reloadable {
+	r := &Reloadable{}
+	r.current.Store(e)
+	return r
+}
+
+// Current returns the Enricher serving new lookups.
+func (r *Reloadable) Current() *Enricher {
+	return r.current.Load()
+}
+
+// Reload builds an Enricher from cfg and swaps it in. On error the current one stays in use.
+// The cache settings of cfg are ignored: changing them requires a restart.
+func (r *Reloadable) Reload(cfg *config.Config) error {
+	next, err := NewEnricher(cfg)
+	if err != nil {
+		return err
+	}
+	prev := r.current.Load()
+	next.Cache, next.counts = prev.Cache, prev.counts
+	if prev.Age.Name() == next.Age.Name() {
+		next.AgeBreaker = keepBreaker(prev.AgeBreaker, next.AgeBreaker)
+	}
+	if prev.Gender.Name() == next.Gender.Name() {
+		next.GenderBreaker = keepBreaker(prev.GenderBreaker, next.GenderBreaker)
+	}
+	if prev.Nationality.Name() == next.Nationality.Name() {
+		next.NationalityBreaker = keepBreaker(prev.NationalityBreaker, next.NationalityBreaker)
+	}
+	r.current.Store(next)
+	return nil
+}
+
+// keepBreaker returns prev when next has the same settings, so a reload does not close
+// a circuit that is open for a provider that is still down.
+func keepBreaker(prev, next *Breaker) *Breaker {
+	if prev.Threshold == next.Threshold && prev.Cooldown == next.Cooldown {
+		return prev
+	}
+	return next
+}
+
+func (r *Reloadable) Enrich(ctx context.Context, name string) (*Enriched, error) {
+	return r.current.Load().Enrich(ctx, name)
+}
+
+func (r *Reloadable) Refresh(ctx context.Context, name string) (*Enriched, error) {
+	return r.current.Load().Refresh(ctx, name)
+}
+
+func (r *Reloadable) CacheStats() CacheStats {
+	return r.current.Load().CacheStats()
+}
@@ -0,0 +1,77 @@
+package enrich
+
+import (
+	"TestTask/internal/config"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubAge struct{}
+
+func (stubAge) Name() string { return "stub" }
+
+func (stubAge) Age(context.Context, string) (*AgeEstimate, error) {
+	return &AgeEstimate{Age: 30}, nil
+}
+
+func init() {
+	RegisterAge("stub", func(*config.Config) (AgeProvider, error) { return stubAge{}, nil })
+}
+
+func TestReloadKeepsBreakers(t *testing.T) {
+	tests := []struct {
+		name            string
+		change          func(cfg *config.Config)
+		wantAgeKept     bool
+		wantGenderKept  bool
+		wantCountryKept bool
+	}{
+		{"same settings", func(cfg *config.Config) {}, true, true, true},
+		{"other sections changed", func(cfg *config.Config) { cfg.Retry.Attempts, cfg.Timeouts.Age = 1, time.Second }, true, true, true},
+		{"failures changed", func(cfg *config.Config) { cfg.Breaker.Failures++ }, false, false, false},
+		{"cooldown changed", func(cfg *config.Config) { cfg.Breaker.Cooldown *= 2 }, false, false, false},
+		{"age provider changed", func(cfg *config.Config) { cfg.Providers.Age = "stub" }, false, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			e, err := NewEnricher(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := NewReloadable(e)
+			// Open the age circuit before reloading.
+			for i := 0; i < cfg.Breaker.Failures; i++ {
+				e.AgeBreaker.record(errors.New("upstream down"))
+			}
+
+			next := config.Default()
+			tt.change(next)
+			if err := r.Reload(next); err != nil {
+				t.Fatalf("Reload: %v", err)
+			}
+			got := r.Current()
+			if got == e {
+				t.Fatal("Reload did not swap the Enricher")
+			}
+			for _, b := range []struct {
+				name       string
+				prev, next *Breaker
+				wantKept   bool
+			}{
+				{"age", e.AgeBreaker, got.AgeBreaker, tt.wantAgeKept},
+				{"gender", e.GenderBreaker, got.GenderBreaker, tt.wantGenderKept},
+				{"nationality", e.NationalityBreaker, got.NationalityBreaker, tt.wantCountryKept},
+			} {
+				if kept := b.prev == b.next; kept != b.wantKept {
+					t.Errorf("%s breaker kept = %v, want %v", b.name, kept, b.wantKept)
+				}
+			}
+			if open := !got.AgeBreaker.allow(); open != tt.wantAgeKept {
+				t.Errorf("age circuit open after reload = %v, want %v", open, tt.wantAgeKept)
+			}
+		})
+	}
+}
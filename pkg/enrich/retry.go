@@ -0,0 +1,105 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StatusError is returned when an upstream API answers with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Temporary reports whether the request may succeed if repeated.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func newStatusError(resp *http.Response) *StatusError {
+	err := &StatusError{StatusCode: resp.StatusCode}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, convErr := strconv.Atoi(v); convErr == nil {
+			err.RetryAfter = time.Duration(secs) * time.Second
+		} else if at, convErr := http.ParseTime(v); convErr == nil {
+			err.RetryAfter = time.Until(at)
+		}
+	}
+	return err
+}
+
+// RetryPolicy retries temporary failures with exponential backoff and full jitter.
+// MaxAttempts below 2 disables retries.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func (p RetryPolicy) do(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(p.delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	backoff := p.BaseDelay << (attempt - 1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+	var d time.Duration
+	if backoff > 0 {
+		d = rand.N(backoff + 1)
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > d {
+		d = statusErr.RetryAfter
+	}
+	return d
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	var decodeErr *DecodeError
+	return !errors.As(err, &decodeErr)
+}
+
+// DecodeError is returned when an upstream response body is not the expected JSON.
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string { return "decode response: " + e.Err.Error() }
+
+func (e *DecodeError) Unwrap() error { return e.Err }
@@ -0,0 +1,130 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network error", errors.New("connection reset"), true},
+		{"too many requests", &StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"server error", &StatusError{StatusCode: http.StatusBadGateway}, true},
+		{"wrapped server error", fmt.Errorf("agify: %w", &StatusError{StatusCode: 503}), true},
+		{"client error", &StatusError{StatusCode: http.StatusBadRequest}, false},
+		{"decode error", &DecodeError{Err: errors.New("unexpected EOF")}, false},
+		{"canceled", context.Canceled, false},
+		{"deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), false},
+		{"circuit open", ErrCircuitOpen, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	other := errors.New("connection reset")
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		attempt  int
+		err      error
+		min, max time.Duration
+	}{
+		{"first retry", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 1, other, 0, 100 * time.Millisecond},
+		{"doubles", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 3, other, 0, 400 * time.Millisecond},
+		{"capped", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 10, other, 0, time.Second},
+		{"overflow is capped", RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, 80, other, 0, 5 * time.Second},
+		{"no delay", RetryPolicy{}, 2, other, 0, 0},
+		{"retry-after wins", RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}, 1,
+			&StatusError{StatusCode: 429, RetryAfter: 3 * time.Second}, 3 * time.Second, 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if d := tt.policy.delay(tt.attempt, tt.err); d < tt.min || d > tt.max {
+					t.Fatalf("delay(%d) = %v, want between %v and %v", tt.attempt, d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	temporary := &StatusError{StatusCode: http.StatusServiceUnavailable}
+	permanent := &StatusError{StatusCode: http.StatusNotFound}
+	tests := []struct {
+		name      string
+		attempts  int
+		errs      []error // returned by successive calls, nil afterwards
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", 3, nil, 1, nil},
+		{"recovers", 3, []error{temporary, temporary}, 3, nil},
+		{"gives up", 3, []error{temporary, temporary, temporary, temporary}, 3, temporary},
+		{"permanent error", 3, []error{permanent}, 1, permanent},
+		{"retries disabled", 1, []error{temporary}, 1, temporary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := RetryPolicy{MaxAttempts: tt.attempts}.do(context.Background(), func(context.Context) error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if calls != tt.wantCalls || err != tt.wantErr {
+				t.Errorf("do() called fn %d times and returned %v, want %d times and %v", calls, err, tt.wantCalls, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDoStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	err := policy.do(ctx, func(context.Context) error {
+		calls++
+		cancel()
+		return errors.New("connection reset")
+	})
+	if calls != 1 || err == nil {
+		t.Errorf("do() called fn %d times and returned %v, want one call and its error", calls, err)
+	}
+}
+
+func TestNewStatusErrorRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		if got := newStatusError(resp).RetryAfter; got != tt.want {
+			t.Errorf("Retry-After %q: RetryAfter = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
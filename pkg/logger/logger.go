@@ -1,12 +1,95 @@
+// Package logger provides the process-wide structured logger. Records logged with a
+// context carrying a request ID (see WithRequestID) get a request_id attribute.
 package logger
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
 )
 
-var Logger *log.Logger
+// Output formats accepted by Init.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Logger is the process-wide logger. It writes text at info level until Init configures it.
+var Logger = New(os.Stdout, FormatText, slog.LevelInfo)
+
+// New returns a logger writing records of at least level to w in the given format.
+func New(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if format == FormatJSON {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+// Init replaces Logger, and the slog default, with a logger writing to w. An empty format
+// or level keeps the default, text and info.
+func Init(w io.Writer, format, level string) error {
+	format = strings.ToLower(format)
+	switch format {
+	case "":
+		format = FormatText
+	case FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("unknown log format %q, use text or json", format)
+	}
+	lvl := slog.LevelInfo
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return fmt.Errorf("unknown log level %q, use debug, info, warn or error", level)
+		}
+	}
+
+	Logger = New(w, format, lvl)
+	slog.SetDefault(Logger)
+	return nil
+}
+
+// Fatal logs msg at error level and exits the process.
+func Fatal(msg string, args ...any) {
+	Logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID of the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
 
-func InitLog() {
-	Logger = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Llongfile)
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
 }